@@ -2,12 +2,13 @@ package main
 
 import (
 	_ "image/jpeg"
+	"os"
 	"runtime"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.2/glfw"
 	"github.com/go-gl/mathgl/mgl32"
-	"github.com/jakecoffman/learnopengl/breakout"
+	"github.com/jakecoffman/learnopengl/breakout/eng"
 )
 
 const (
@@ -40,7 +41,9 @@ func main() {
 		panic(err)
 	}
 
-	shader, err := breakout.ResourceManager.LoadShader("4/vertex.glsl", "4/fragment.glsl", "shader")
+	resources := eng.NewResourceManager(os.DirFS("."))
+	defer resources.Clear()
+	shader, err := resources.LoadShader("4/vertex.glsl", "4/fragment.glsl", "shader")
 	if err != nil {
 		panic(err)
 	}
@@ -76,11 +79,11 @@ func main() {
 	gl.VertexAttribPointer(1, 2, gl.FLOAT, false, 5*4, gl.PtrOffset(3*4))
 	gl.EnableVertexAttribArray(1)
 
-	texture1, err := breakout.ResourceManager.LoadTexture("3/container.jpg", "container")
+	texture1, err := resources.LoadTexture("3/container.jpg", "container")
 	if err != nil {
 		panic(err)
 	}
-	texture2, err := breakout.ResourceManager.LoadTexture("4/awesomeface.png", "face")
+	texture2, err := resources.LoadTexture("4/awesomeface.png", "face")
 	if err != nil {
 		panic(err)
 	}
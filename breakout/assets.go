@@ -0,0 +1,8 @@
+package breakout
+
+import "embed"
+
+// Assets holds the shaders, textures and levels the game ships with.
+//
+//go:embed shaders textures levels
+var Assets embed.FS
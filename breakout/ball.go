@@ -2,17 +2,19 @@ package breakout
 
 import (
 	"github.com/go-gl/mathgl/mgl32"
-	"github.com/jakecoffman/learnopengl/breakout/eng"
 )
 
 type Ball struct {
 	*Object
 
 	Radius float32
-	Stuck bool
+	Stuck  bool
+
+	// set by power-ups
+	Sticky, PassThrough bool
 }
 
-func NewBall(pos mgl32.Vec2, radius float32, velocity mgl32.Vec2, sprite *eng.Texture2D) *Ball {
+func NewBall(pos mgl32.Vec2, radius float32, velocity mgl32.Vec2, sprite string) *Ball {
 	ball := &Ball{}
 	ball.Object = NewGameObject(pos, mgl32.Vec2{radius * 2, radius * 2}, sprite)
 	ball.Color = mgl32.Vec3{1, 1, 1}
@@ -22,25 +24,32 @@ func NewBall(pos mgl32.Vec2, radius float32, velocity mgl32.Vec2, sprite *eng.Te
 	return ball
 }
 
-func (b *Ball) Move(dt, windowWidth float32) mgl32.Vec2 {
-	if b.Stuck {
-		return b.Position
-	}
+// Center returns the middle of the ball, Position being its top left corner.
+func (b *Ball) Center() mgl32.Vec2 {
+	return b.Position.Add(mgl32.Vec2{b.Radius, b.Radius})
+}
 
-	b.Position = b.Position.Add(b.Velocity.Mul(dt))
+// bounceWalls keeps the ball inside the left, right and top walls, turning
+// it back from a wall it is moving into. One already moving away from a wall
+// it touches is left to carry on, so it can't be flipped back into it.
+func (b *Ball) bounceWalls(windowWidth float32) {
 	if b.Position.X() <= 0 {
-		b.Velocity = mgl32.Vec2{-b.Velocity.X(), b.Velocity.Y()}
-		b.Position = mgl32.Vec2{0, b.Position.Y()}
-	} else if b.Position.X() + b.Size.X() >= windowWidth {
-		b.Velocity = mgl32.Vec2{-b.Velocity.X(), b.Velocity.Y()}
-		b.Position = mgl32.Vec2{windowWidth-b.Size.X(), b.Position.Y()}
+		if b.Velocity.X() < 0 {
+			b.Velocity[0] = -b.Velocity.X()
+		}
+		b.Position[0] = 0
+	} else if b.Position.X()+b.Size.X() >= windowWidth {
+		if b.Velocity.X() > 0 {
+			b.Velocity[0] = -b.Velocity.X()
+		}
+		b.Position[0] = windowWidth - b.Size.X()
 	}
 	if b.Position.Y() <= 0 {
-		b.Velocity = mgl32.Vec2{b.Velocity.X(), -b.Velocity.Y()}
-		b.Position = mgl32.Vec2{b.Position.X(), 0}
+		if b.Velocity.Y() < 0 {
+			b.Velocity[1] = -b.Velocity.Y()
+		}
+		b.Position[1] = 0
 	}
-
-	return b.Position
 }
 
 func (b *Ball) Reset(position, velocity mgl32.Vec2) {
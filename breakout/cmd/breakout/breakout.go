@@ -1,11 +1,103 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+
 	"github.com/jakecoffman/learnopengl/breakout"
 	"github.com/jakecoffman/learnopengl/breakout/eng"
+	"github.com/jakecoffman/learnopengl/breakout/game"
 )
 
+// overlays collects every -assets flag in the order given.
+type overlays []string
+
+func (o *overlays) String() string {
+	return strings.Join(*o, ",")
+}
+
+func (o *overlays) Set(path string) error {
+	*o = append(*o, path)
+	return nil
+}
+
 func main() {
-	Breakout := &breakout.Game{}
-	eng.Run(Breakout, 800, 600)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: breakout [flags]\n       breakout [flags] lint-levels [file or directory...]\n")
+		flag.PrintDefaults()
+	}
+	var assets overlays
+	flag.Var(&assets, "assets", "directory or zip of assets to use over the built in ones, may be repeated with later ones on top")
+	watch := flag.Bool("watch", false, "reload shaders, textures and levels when their files change in an -assets directory; the built in assets and zips never change")
+	edit := flag.String("edit", "", "directory the level editor saves to, laid out like -assets; the editor is off without it")
+	configFile := flag.String("config", "", "JSON config file, flags win over it (default breakout/config.json in the user config directory)")
+	timestep := flag.Float64("timestep", eng.DefaultStep, "seconds of game time each update runs for")
+	timescale := flag.Float64("timescale", 1, "how fast game time passes, below 1 for slow motion")
+	maxFrame := flag.Float64("max-frame-time", .25, "most seconds of game time caught up on after a slow frame, 0 for no limit")
+	step := flag.Bool("step", false, "start with time stopped, F10 stepping one update and F9 resuming")
+	record := flag.String("record", "", "file to save the session's input to on exit, to play back with -replay")
+	replay := flag.String("replay", "", "file of input recorded with -record to play back in place of the keyboard, mouse and joystick")
+	defaults := eng.DefaultWindowOptions
+	defaults.Title = "Breakout"
+	flagged := defaults
+	windowFlags(flag.CommandLine, &flagged)
+	controlFlags(flag.CommandLine, &breakout.Controls{})
+	flag.Parse()
+
+	// later flags win, so they go first
+	layers := []fs.FS{breakout.Assets}
+	watchable := false
+	for _, path := range assets {
+		fsys, closer, err := eng.OpenFS(path)
+		if err != nil {
+			log.Fatal(fmt.Errorf("-assets: %w", err))
+		}
+		defer closer.Close()
+		layers = append([]fs.FS{fsys}, layers...)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			watchable = true
+		}
+	}
+	if *watch && !watchable {
+		log.Print("-watch has nothing to watch: only -assets directories change, not the built in assets or zips")
+	}
+
+	switch flag.Arg(0) {
+	case "":
+	case "lint-levels":
+		if !lintLevels(eng.OverlayFS(layers...), flag.Args()[1:]) {
+			os.Exit(1)
+		}
+		return
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	config, err := readConfig(*configFile, defaults)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *timestep <= 0 || *timescale <= 0 || *maxFrame < 0 {
+		log.Fatal("-timestep and -timescale must be positive and -max-frame-time not negative")
+	}
+	loop := eng.NewLoop(eng.GLFWClock)
+	loop.Step = *timestep
+	loop.TimeScale = *timescale
+	loop.MaxFrameTime = *maxFrame
+	loop.Paused = *step
+
+	Breakout := &game.Game{FS: eng.OverlayFS(layers...), Watch: *watch, EditDir: *edit, Loop: loop, RecordFile: *record, Bindings: config.Bindings, Controls: config.Controls, WindowOptions: config.Window}
+	if *replay != "" {
+		if Breakout.Replay, err = breakout.LoadReplay(*replay); err != nil {
+			log.Fatal(err)
+		}
+		// the replay only comes out the same at the step it was recorded at
+		loop.Step = float64(Breakout.Replay.Step)
+	}
+	eng.Run(Breakout, 800, 600, config.Window, loop)
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/jakecoffman/learnopengl/breakout"
+	"github.com/jakecoffman/learnopengl/breakout/eng"
+	"github.com/jakecoffman/learnopengl/breakout/input"
+)
+
+// config is the config file, e.g.
+//
+//	{
+//	  "window": {"mode": "borderless", "monitor": 1, "samples": 4},
+//	  "bindings": {"launch": ["Space", "Up", "Button0"]},
+//	  "controls": {"mouseSensitivity": 1.5}
+//	}
+//
+// Options and actions left out keep their defaults.
+type config struct {
+	Window   eng.WindowOptions `json:"window"`
+	Bindings input.Bindings    `json:"bindings"`
+	Controls breakout.Controls `json:"controls"`
+}
+
+// defaultConfigFile is where the config file is looked for without -config,
+// e.g. ~/.config/breakout/config.json on Linux.
+func defaultConfigFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "breakout", "config.json"), nil
+}
+
+// windowFlags adds a flag for each window option to flags, defaulting to and
+// setting o.
+func windowFlags(flags *flag.FlagSet, o *eng.WindowOptions) {
+	flags.Var(&o.Mode, "mode", "window mode: windowed, fullscreen or borderless")
+	flags.StringVar(&o.Title, "title", o.Title, "window title")
+	flags.IntVar(&o.Width, "width", o.Width, "window width, or of the video mode when fullscreen; 0 for the default")
+	flags.IntVar(&o.Height, "height", o.Height, "window height, or of the video mode when fullscreen; 0 for the default")
+	flags.IntVar(&o.Monitor, "monitor", o.Monitor, "index of the monitor to open on, 0 for the primary one")
+	flags.BoolVar(&o.VSync, "vsync", o.VSync, "wait for vertical sync")
+	flags.IntVar(&o.Samples, "msaa", o.Samples, "samples per pixel for antialiasing, 0 for none")
+	flags.BoolVar(&o.Resizable, "resizable", o.Resizable, "let the window be resized")
+	flags.BoolVar(&o.Debug, "gldebug", o.Debug, "create a GL debug context and log its messages")
+}
+
+// float32Value is a float32 flag.
+type float32Value float32
+
+func (f *float32Value) String() string {
+	return strconv.FormatFloat(float64(*f), 'g', -1, 32)
+}
+
+func (f *float32Value) Set(s string) error {
+	v, err := strconv.ParseFloat(s, 32)
+	*f = float32Value(v)
+	return err
+}
+
+// controlFlags adds a flag for each of the paddle's controls to flags,
+// defaulting to and setting c.
+func controlFlags(flags *flag.FlagSet, c *breakout.Controls) {
+	flags.Var((*float32Value)(&c.MouseSensitivity), "mouse-sensitivity", "how fast the paddle follows the mouse, 1 if 0; negative to not use the mouse")
+	flags.Var((*float32Value)(&c.Deadzone), "deadzone", "how far from the center a stick is ignored, from 0 to 1; 0 for the default")
+}
+
+// readConfig reads the config starting from the default window options,
+// then the config file at path, or the default one if it exists, then the
+// flags given on the command line. Call it once flag.CommandLine is parsed.
+func readConfig(path string, defaults eng.WindowOptions) (config, error) {
+	c := config{Window: defaults}
+	explicit := path != ""
+	if !explicit {
+		var err error
+		if path, err = defaultConfigFile(); err != nil {
+			path = ""
+		}
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist) && !explicit:
+		case err != nil:
+			return c, err
+		default:
+			dec := json.NewDecoder(bytes.NewReader(data))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(&c); err != nil {
+				return c, fmt.Errorf("config %s: %w", path, err)
+			}
+			actions := breakout.DefaultBindings()
+			for action := range c.Bindings {
+				if _, ok := actions[action]; !ok {
+					return c, fmt.Errorf("config %s: unknown action %q", path, action)
+				}
+			}
+		}
+	}
+
+	// flags given win over the file
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	windowFlags(flags, &c.Window)
+	controlFlags(flags, &c.Controls)
+	var err error
+	flag.Visit(func(f *flag.Flag) {
+		if flags.Lookup(f.Name) != nil && err == nil {
+			err = flags.Set(f.Name, f.Value.String())
+		}
+	})
+	return c, err
+}
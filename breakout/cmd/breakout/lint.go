@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/jakecoffman/learnopengl/breakout"
+)
+
+// lintLevels validates the level files and directories in args, or the
+// levels in fsys without any, and prints every problem it finds. It reports
+// whether the levels are all valid.
+func lintLevels(fsys fs.FS, args []string) bool {
+	var diagnostics []breakout.Diagnostic
+	if len(args) == 0 {
+		found, err := breakout.ValidateLevels(fsys, "levels")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return false
+		}
+		diagnostics = found
+	}
+	for _, arg := range args {
+		found, err := lintPath(arg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return false
+		}
+		diagnostics = append(diagnostics, found...)
+	}
+
+	for _, d := range diagnostics {
+		fmt.Println(d)
+	}
+	return len(diagnostics) == 0
+}
+
+// lintPath validates a level file or a directory of them on disk.
+func lintPath(path string) ([]breakout.Diagnostic, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return breakout.ValidateLevel(path, data), nil
+	}
+
+	diagnostics, err := breakout.ValidateLevels(os.DirFS(path), ".")
+	if err != nil {
+		return nil, err
+	}
+	for i := range diagnostics {
+		diagnostics[i].File = filepath.Join(path, diagnostics[i].File)
+	}
+	return diagnostics, nil
+}
@@ -0,0 +1,152 @@
+package breakout
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// maxBallHits bounds how many impacts are resolved in a single step so a
+// ball wedged between objects can't spin the loop forever.
+const maxBallHits = 16
+
+func checkCollision(one, two *Object) bool {
+	collisionX := one.Position.X()+one.Size.X() >= two.Position.X() && two.Position.X()+two.Size.X() >= one.Position.X()
+	collisionY := one.Position.Y()+one.Size.Y() >= two.Position.Y() && two.Position.Y()+two.Size.Y() >= one.Position.Y()
+	return collisionX && collisionY
+}
+
+// sweepBall moves a circle of the given radius from center by displacement
+// and returns the fraction of the displacement in [0, 1] at which it first
+// touches the box, along with the surface normal at the point of impact. A
+// circle that already overlaps the box reports an impact at 0 if it is moving
+// further into it; one moving out is left alone.
+func sweepBall(center mgl32.Vec2, radius float32, displacement mgl32.Vec2, box *Object) (bool, float32, mgl32.Vec2) {
+	min := box.Position
+	max := box.Position.Add(box.Size)
+
+	closest := mgl32.Vec2{
+		mgl32.Clamp(center.X(), min.X(), max.X()),
+		mgl32.Clamp(center.Y(), min.Y(), max.Y()),
+	}
+	difference := center.Sub(closest)
+	if difference.Len() < radius {
+		normal := overlapNormal(center, difference, min, max)
+		if displacement.Dot(normal) < 0 {
+			return true, 0, normal
+		}
+		return false, 0, mgl32.Vec2{}
+	}
+
+	// The set of centers that touch the box is the box with its corners
+	// rounded by radius: two crossed rectangles plus a circle on each corner.
+	hit := false
+	var toi float32 = 1
+	var normal mgl32.Vec2
+
+	wide := [2]mgl32.Vec2{{min.X() - radius, min.Y()}, {max.X() + radius, max.Y()}}
+	tall := [2]mgl32.Vec2{{min.X(), min.Y() - radius}, {max.X(), max.Y() + radius}}
+	for _, rect := range [][2]mgl32.Vec2{wide, tall} {
+		if ok, t, n := rayBox(center, displacement, rect[0], rect[1]); ok && t <= toi {
+			hit, toi, normal = true, t, n
+		}
+	}
+	corners := []mgl32.Vec2{min, {max.X(), min.Y()}, max, {min.X(), max.Y()}}
+	for _, corner := range corners {
+		if ok, t, n := rayCircle(center, displacement, corner, radius); ok && t <= toi {
+			hit, toi, normal = true, t, n
+		}
+	}
+	if !hit || displacement.Dot(normal) >= 0 {
+		return false, 0, mgl32.Vec2{}
+	}
+	return true, toi, normal
+}
+
+// overlapNormal picks the direction to push a circle out of a box it is
+// already overlapping. If the center is inside the box the shallowest face
+// wins.
+func overlapNormal(center, difference, min, max mgl32.Vec2) mgl32.Vec2 {
+	if difference.Len() > 0 {
+		return difference.Normalize()
+	}
+	faces := []struct {
+		depth  float32
+		normal mgl32.Vec2
+	}{
+		{center.X() - min.X(), mgl32.Vec2{-1, 0}},
+		{max.X() - center.X(), mgl32.Vec2{1, 0}},
+		{center.Y() - min.Y(), mgl32.Vec2{0, -1}},
+		{max.Y() - center.Y(), mgl32.Vec2{0, 1}},
+	}
+	best := faces[0]
+	for _, face := range faces[1:] {
+		if face.depth < best.depth {
+			best = face
+		}
+	}
+	return best.normal
+}
+
+// rayBox returns where the segment origin+t*direction, t in [0, 1], enters
+// the box and the normal of the face it enters through.
+func rayBox(origin, direction, min, max mgl32.Vec2) (bool, float32, mgl32.Vec2) {
+	enter := float32(math.Inf(-1))
+	exit := float32(math.Inf(1))
+	var normal mgl32.Vec2
+
+	for axis := 0; axis < 2; axis++ {
+		if direction[axis] == 0 {
+			if origin[axis] < min[axis] || origin[axis] > max[axis] {
+				return false, 0, mgl32.Vec2{}
+			}
+			continue
+		}
+		near := (min[axis] - origin[axis]) / direction[axis]
+		far := (max[axis] - origin[axis]) / direction[axis]
+		var n mgl32.Vec2
+		n[axis] = -1
+		if near > far {
+			near, far = far, near
+			n[axis] = 1
+		}
+		if near > enter {
+			enter = near
+			normal = n
+		}
+		if far < exit {
+			exit = far
+		}
+	}
+	if enter > exit || enter < 0 || enter > 1 {
+		return false, 0, mgl32.Vec2{}
+	}
+	return true, enter, normal
+}
+
+// rayCircle returns where the segment origin+t*direction, t in [0, 1], first
+// touches the circle and the circle's normal at that point.
+func rayCircle(origin, direction, center mgl32.Vec2, radius float32) (bool, float32, mgl32.Vec2) {
+	m := origin.Sub(center)
+	a := direction.Dot(direction)
+	if a == 0 {
+		return false, 0, mgl32.Vec2{}
+	}
+	b := m.Dot(direction)
+	c := m.Dot(m) - radius*radius
+	discriminant := b*b - a*c
+	if discriminant < 0 {
+		return false, 0, mgl32.Vec2{}
+	}
+	t := (-b - float32(math.Sqrt(float64(discriminant)))) / a
+	if t < 0 || t > 1 {
+		return false, 0, mgl32.Vec2{}
+	}
+	normal := origin.Add(direction.Mul(t)).Sub(center).Normalize()
+	return true, t, normal
+}
+
+// reflect mirrors velocity about the plane with the given unit normal.
+func reflect(velocity, normal mgl32.Vec2) mgl32.Vec2 {
+	return velocity.Sub(normal.Mul(2 * velocity.Dot(normal)))
+}
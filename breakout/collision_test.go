@@ -0,0 +1,152 @@
+package breakout
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/jakecoffman/learnopengl/breakout/input"
+)
+
+func TestSweepBall(t *testing.T) {
+	// the box spans 100 to 200 across and 100 to 150 down
+	box := NewGameObject(mgl32.Vec2{100, 100}, mgl32.Vec2{100, 50}, BlockSprite)
+	const radius = 10
+	diagonal := float32(1 / math.Sqrt2)
+
+	tests := []struct {
+		name         string
+		center, move mgl32.Vec2
+		hit          bool
+		toi          float32
+		normal       mgl32.Vec2
+	}{
+		{"miss", mgl32.Vec2{50, 50}, mgl32.Vec2{0, -40}, false, 0, mgl32.Vec2{}},
+		{"falls short", mgl32.Vec2{150, 0}, mgl32.Vec2{0, 80}, false, 0, mgl32.Vec2{}},
+		{"top face", mgl32.Vec2{150, 80}, mgl32.Vec2{0, 40}, true, .25, mgl32.Vec2{0, -1}},
+		{"left face", mgl32.Vec2{50, 125}, mgl32.Vec2{100, 0}, true, .4, mgl32.Vec2{-1, 0}},
+		{"bottom face", mgl32.Vec2{150, 200}, mgl32.Vec2{0, -100}, true, .4, mgl32.Vec2{0, 1}},
+		// fast enough to be past the box by the end of the step
+		{"tunnelling down", mgl32.Vec2{150, 0}, mgl32.Vec2{0, 1000}, true, .09, mgl32.Vec2{0, -1}},
+		{"tunnelling across", mgl32.Vec2{0, 125}, mgl32.Vec2{5000, 0}, true, .018, mgl32.Vec2{-1, 0}},
+		{"corner head on", mgl32.Vec2{70, 70}, mgl32.Vec2{40, 40}, true, .573223, mgl32.Vec2{-diagonal, -diagonal}},
+		// 5 from the left side, so it touches the corner 8.66 above the top
+		{"corner glancing", mgl32.Vec2{95, 0}, mgl32.Vec2{0, 200}, true, .456699, mgl32.Vec2{-.5, -.866025}},
+		{"beside the corner", mgl32.Vec2{85, 0}, mgl32.Vec2{0, 200}, false, 0, mgl32.Vec2{}},
+		// cuts the corner of the box grown by the radius, but not the
+		// rounded corner the ball actually touches
+		{"past the rounded corner", mgl32.Vec2{72, 112}, mgl32.Vec2{40, -40}, false, 0, mgl32.Vec2{}},
+		{"touching, moving in", mgl32.Vec2{150, 95}, mgl32.Vec2{0, 10}, true, 0, mgl32.Vec2{0, -1}},
+		{"touching, moving out", mgl32.Vec2{150, 95}, mgl32.Vec2{0, -10}, false, 0, mgl32.Vec2{}},
+		{"touching, moving along", mgl32.Vec2{150, 95}, mgl32.Vec2{10, 0}, false, 0, mgl32.Vec2{}},
+		{"center inside", mgl32.Vec2{150, 105}, mgl32.Vec2{0, 10}, true, 0, mgl32.Vec2{0, -1}},
+		{"still", mgl32.Vec2{150, 80}, mgl32.Vec2{}, false, 0, mgl32.Vec2{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hit, toi, normal := sweepBall(test.center, radius, test.move, box)
+			if hit != test.hit {
+				t.Fatalf("hit = %v, want %v", hit, test.hit)
+			}
+			if !hit {
+				return
+			}
+			if !mgl32.FloatEqualThreshold(toi, test.toi, 1e-4) {
+				t.Errorf("toi = %v, want %v", toi, test.toi)
+			}
+			if !normal.ApproxEqualThreshold(test.normal, 1e-4) {
+				t.Errorf("normal = %v, want %v", normal, test.normal)
+			}
+		})
+	}
+}
+
+func TestBounceWalls(t *testing.T) {
+	tests := []struct {
+		name               string
+		position, velocity mgl32.Vec2
+		want               mgl32.Vec2
+	}{
+		{"into the left wall", mgl32.Vec2{-5, 100}, mgl32.Vec2{-100, -100}, mgl32.Vec2{100, -100}},
+		{"away from the left wall", mgl32.Vec2{-5, 100}, mgl32.Vec2{100, -100}, mgl32.Vec2{100, -100}},
+		{"into the right wall", mgl32.Vec2{760, 100}, mgl32.Vec2{100, -100}, mgl32.Vec2{-100, -100}},
+		{"away from the right wall", mgl32.Vec2{760, 100}, mgl32.Vec2{-100, -100}, mgl32.Vec2{-100, -100}},
+		{"into the top", mgl32.Vec2{100, -5}, mgl32.Vec2{100, -100}, mgl32.Vec2{100, 100}},
+		{"away from the top", mgl32.Vec2{100, -5}, mgl32.Vec2{100, 100}, mgl32.Vec2{100, 100}},
+		{"into the corner", mgl32.Vec2{-5, -5}, mgl32.Vec2{-100, -100}, mgl32.Vec2{100, 100}},
+		{"clear of the walls", mgl32.Vec2{100, 100}, mgl32.Vec2{-100, -100}, mgl32.Vec2{-100, -100}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ball := NewBall(test.position, 25, test.velocity, BallSprite)
+			ball.bounceWalls(800)
+			if ball.Velocity != test.want {
+				t.Errorf("velocity = %v, want %v", ball.Velocity, test.want)
+			}
+			if x, y := ball.Position.X(), ball.Position.Y(); x < 0 || x+ball.Size.X() > 800 || y < 0 {
+				t.Errorf("ball left at %v, outside the walls", ball.Position)
+			}
+		})
+	}
+}
+
+// fire launches the ball from under the bottom of the level straight up,
+// fast enough to cross the whole level in one step. The paddle is moved
+// aside so the ball doesn't come back off it within the step.
+func fire(s *Simulation) {
+	s.Player.Position[0] = 0
+	s.Ball.Stuck = false
+	s.Ball.Position = mgl32.Vec2{testWidth/2 - s.Ball.Radius, testHeight/2 + 10}
+	s.Ball.Velocity = mgl32.Vec2{0, -100000}
+}
+
+func TestCollisionsInOrder(t *testing.T) {
+	// two rows of one brick each, the ball coming up from below
+	s := newTestSimulation(t, "2\n2")
+	press(s, input.KeyEnter)
+	var destroyed []*Object
+	s.BrickDestroyed = func(brick *Object) {
+		destroyed = append(destroyed, brick)
+	}
+	top, bottom := s.Levels[0].Bricks[0], s.Levels[0].Bricks[1]
+
+	fire(s)
+	s.doCollisions(testStep)
+	if len(destroyed) != 1 || destroyed[0] != bottom {
+		t.Fatalf("destroyed %v, want only the bottom brick", destroyed)
+	}
+	if top.Destroyed {
+		t.Error("ball went through the bottom brick to the top one")
+	}
+	if s.Ball.Velocity.Y() <= 0 {
+		t.Errorf("ball velocity %v, want bounced back down", s.Ball.Velocity)
+	}
+	if s.Ball.Position.Y() < bottom.Position.Y()+bottom.Size.Y() {
+		t.Errorf("ball at %v, inside the bottom brick", s.Ball.Position)
+	}
+}
+
+func TestCollisionsPassThroughInOrder(t *testing.T) {
+	// a solid row over two breakable ones
+	s := newTestSimulation(t, "1\n2\n2")
+	press(s, input.KeyEnter)
+	var destroyed []*Object
+	s.BrickDestroyed = func(brick *Object) {
+		destroyed = append(destroyed, brick)
+	}
+	bricks := s.Levels[0].Bricks
+
+	s.Ball.PassThrough = true
+	fire(s)
+	s.doCollisions(testStep)
+
+	if len(destroyed) != 2 || destroyed[0] != bricks[2] || destroyed[1] != bricks[1] {
+		t.Fatalf("destroyed %v, want the bottom brick then the middle one", destroyed)
+	}
+	if bricks[0].Destroyed {
+		t.Error("solid brick destroyed")
+	}
+	if s.ShakeTime <= 0 || s.Ball.Velocity.Y() <= 0 {
+		t.Errorf("ball velocity %v, shake %v, want bounced off the solid brick", s.Ball.Velocity, s.ShakeTime)
+	}
+}
@@ -0,0 +1,205 @@
+package breakout
+
+import "sort"
+
+// limits on the grid the editor resizes a level to
+const (
+	maxEditorColumns = 40
+	maxEditorRows    = 30
+)
+
+// eraser is the brush that paints no brick.
+const eraser = "."
+
+// Editor paints bricks onto a level's tiles with undo and redo. It is GL
+// free, the game draws the level and the editor's grid around it.
+type Editor struct {
+	Level *Level
+	// Brush is the palette key painted, or "." to erase.
+	Brush string
+	// Status is a message for the player, e.g. whether the level saved.
+	Status string
+
+	undo, redo [][][]string
+	// saved is the tiles as they were last saved, or loaded
+	saved [][]string
+	// stroke is set while a mouse button is held, so a whole stroke is
+	// undone at once
+	stroke bool
+}
+
+// NewEditor edits level, starting with its first brush.
+func NewEditor(level *Level) *Editor {
+	e := &Editor{Level: level, Brush: eraser, saved: copyTiles(level.Tiles)}
+	if brushes := e.Brushes(); len(brushes) > 1 {
+		e.Brush = brushes[1]
+	}
+	return e
+}
+
+// Brushes are the keys that can be painted: the eraser then the palette in
+// order.
+func (e *Editor) Brushes() []string {
+	keys := []string{eraser}
+	for key := range e.Level.Palette {
+		if !emptyTile(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys[1:])
+	return keys
+}
+
+// SelectBrush picks the i-th of Brushes, wrapping around in either
+// direction.
+func (e *Editor) SelectBrush(i int) {
+	brushes := e.Brushes()
+	i %= len(brushes)
+	if i < 0 {
+		i += len(brushes)
+	}
+	e.Brush = brushes[i]
+}
+
+// brushIndex is the position of Brush in Brushes.
+func (e *Editor) brushIndex() int {
+	for i, key := range e.Brushes() {
+		if key == e.Brush {
+			return i
+		}
+	}
+	return 0
+}
+
+// Size is the level's grid in columns and rows.
+func (e *Editor) Size() (columns, rows int) {
+	if len(e.Level.Tiles) == 0 {
+		return 0, 0
+	}
+	return len(e.Level.Tiles[0]), len(e.Level.Tiles)
+}
+
+// Paint sets the tile at column and row to key. Everything painted until
+// EndStroke is undone together.
+func (e *Editor) Paint(column, row int, key string) {
+	columns, rows := e.Size()
+	if column < 0 || column >= columns || row < 0 || row >= rows || e.Level.Tiles[row][column] == key {
+		return
+	}
+	if !e.stroke {
+		e.save()
+		e.stroke = true
+	}
+	e.Level.Tiles[row][column] = key
+	e.Level.Reset()
+}
+
+// EndStroke finishes the stroke Paint started.
+func (e *Editor) EndStroke() {
+	e.stroke = false
+}
+
+// Resize grows or shrinks the grid from its right and bottom edges, filling
+// new tiles with no brick.
+func (e *Editor) Resize(columns, rows int) {
+	if columns < 1 || rows < 1 || columns > maxEditorColumns || rows > maxEditorRows {
+		return
+	}
+	if c, r := e.Size(); c == columns && r == rows {
+		return
+	}
+	e.save()
+	tiles := make([][]string, rows)
+	for y := range tiles {
+		tiles[y] = make([]string, columns)
+		for x := range tiles[y] {
+			tiles[y][x] = eraser
+			if y < len(e.Level.Tiles) && x < len(e.Level.Tiles[y]) {
+				tiles[y][x] = e.Level.Tiles[y][x]
+			}
+		}
+	}
+	e.Level.Tiles = tiles
+	e.Level.Reset()
+}
+
+// Undo takes back the last change, reporting whether there was one.
+func (e *Editor) Undo() bool {
+	if len(e.undo) == 0 {
+		return false
+	}
+	e.redo = append(e.redo, copyTiles(e.Level.Tiles))
+	e.restore(&e.undo)
+	return true
+}
+
+// Redo puts back the last change undone, reporting whether there was one.
+func (e *Editor) Redo() bool {
+	if len(e.redo) == 0 {
+		return false
+	}
+	e.undo = append(e.undo, copyTiles(e.Level.Tiles))
+	e.restore(&e.redo)
+	return true
+}
+
+// Modified reports whether the tiles have changed since they were last
+// saved.
+func (e *Editor) Modified() bool {
+	if len(e.Level.Tiles) != len(e.saved) {
+		return true
+	}
+	for y, row := range e.Level.Tiles {
+		if len(row) != len(e.saved[y]) {
+			return true
+		}
+		for x, key := range row {
+			if e.saved[y][x] != key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Reloaded takes the level's tiles as saved, for when its file has been
+// loaded again and they are what is on disk.
+func (e *Editor) Reloaded() {
+	e.saved = copyTiles(e.Level.Tiles)
+	e.stroke = false
+}
+
+// Revert puts back the tiles as they were last saved, as a change that can
+// be undone, reporting whether there was anything to put back. The level is
+// the one the menu plays, so leaving the editor reverts it.
+func (e *Editor) Revert() bool {
+	if !e.Modified() {
+		return false
+	}
+	e.save()
+	e.Level.Tiles = copyTiles(e.saved)
+	e.Level.Reset()
+	return true
+}
+
+// save remembers the tiles before a change, which can't be redone past.
+func (e *Editor) save() {
+	e.undo = append(e.undo, copyTiles(e.Level.Tiles))
+	e.redo = nil
+}
+
+// restore pops tiles off stack into the level.
+func (e *Editor) restore(stack *[][][]string) {
+	last := len(*stack) - 1
+	e.Level.Tiles = (*stack)[last]
+	*stack = (*stack)[:last]
+	e.Level.Reset()
+}
+
+func copyTiles(tiles [][]string) [][]string {
+	c := make([][]string, len(tiles))
+	for i, row := range tiles {
+		c[i] = append([]string(nil), row...)
+	}
+	return c
+}
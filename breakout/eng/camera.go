@@ -0,0 +1,122 @@
+package eng
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Projector is a renderer that draws through a projection matrix.
+type Projector interface {
+	SetProjection(projection mgl32.Mat4)
+}
+
+// Camera2D looks at a world drawn at a fixed virtual resolution, letterboxed
+// into the window's framebuffer whatever its size. World coordinates start at
+// the top left with y down, so with the camera at rest a point in the world
+// is the same point on the virtual screen.
+type Camera2D struct {
+	// Width and Height are the virtual resolution.
+	Width, Height float32
+	// Position is the top left of the view in the world, Zoom magnifies it
+	// and Rotation turns it around its center, in radians.
+	Position mgl32.Vec2
+	Zoom     float32
+	Rotation float32
+
+	// the letterboxed viewport in framebuffer pixels, from the bottom left as
+	// GL has it, and the framebuffer's height
+	viewport      [4]int32
+	framebufferH  int32
+	world, screen []Projector
+}
+
+// NewCamera2D creates a camera for a width by height virtual screen, at
+// rest. Call Resize with the framebuffer size before drawing.
+func NewCamera2D(width, height float32) *Camera2D {
+	return &Camera2D{
+		Width:        width,
+		Height:       height,
+		Zoom:         1,
+		viewport:     [4]int32{0, 0, int32(width), int32(height)},
+		framebufferH: int32(height),
+	}
+}
+
+// Attach has the camera's projection fed to renderers that draw the world.
+func (c *Camera2D) Attach(renderers ...Projector) {
+	c.world = append(c.world, renderers...)
+}
+
+// AttachScreen has the virtual screen's projection, which doesn't move,
+// zoom or rotate with the camera, fed to renderers that draw e.g. a HUD.
+func (c *Camera2D) AttachScreen(renderers ...Projector) {
+	c.screen = append(c.screen, renderers...)
+}
+
+// Resize fits the virtual resolution into a framebuffer of the given size,
+// as large as it goes with black bars along the sides it doesn't fill.
+func (c *Camera2D) Resize(width, height int) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	scale := float32(width) / c.Width
+	if s := float32(height) / c.Height; s < scale {
+		scale = s
+	}
+	w, h := int32(c.Width*scale), int32(c.Height*scale)
+	c.viewport = [4]int32{(int32(width) - w) / 2, (int32(height) - h) / 2, w, h}
+	c.framebufferH = int32(height)
+}
+
+// Viewport is the letterboxed part of the framebuffer drawn to, in pixels
+// from the bottom left.
+func (c *Camera2D) Viewport() (x, y, width, height int32) {
+	return c.viewport[0], c.viewport[1], c.viewport[2], c.viewport[3]
+}
+
+// Apply sets the viewport and feeds the projections to the attached
+// renderers. Call it every frame before drawing, as the camera may move.
+func (c *Camera2D) Apply() {
+	gl.Viewport(c.viewport[0], c.viewport[1], c.viewport[2], c.viewport[3])
+	projection := c.Projection()
+	for _, r := range c.world {
+		r.SetProjection(projection)
+	}
+	screen := c.ScreenProjection()
+	for _, r := range c.screen {
+		r.SetProjection(screen)
+	}
+}
+
+// View transforms the world into the virtual screen.
+func (c *Camera2D) View() mgl32.Mat4 {
+	center := c.Position.Add(mgl32.Vec2{c.Width / 2, c.Height / 2})
+	return mgl32.Translate3D(c.Width/2, c.Height/2, 0).
+		Mul4(mgl32.HomogRotate3DZ(c.Rotation)).
+		Mul4(mgl32.Scale3D(c.Zoom, c.Zoom, 1)).
+		Mul4(mgl32.Translate3D(-center.X(), -center.Y(), 0))
+}
+
+// Projection transforms the world into clip space.
+func (c *Camera2D) Projection() mgl32.Mat4 {
+	return c.ScreenProjection().Mul4(c.View())
+}
+
+// ScreenProjection transforms the virtual screen into clip space.
+func (c *Camera2D) ScreenProjection() mgl32.Mat4 {
+	return mgl32.Ortho(0, c.Width, c.Height, 0, -1, 1)
+}
+
+// Unproject finds the point in the world under a point in the framebuffer,
+// in pixels from its top left as cursor positions are.
+func (c *Camera2D) Unproject(pixel mgl32.Vec2) mgl32.Vec2 {
+	x, y, w, h := c.Viewport()
+	top := c.framebufferH - y - h
+	screen := mgl32.Vec4{
+		(pixel.X() - float32(x)) / float32(w) * c.Width,
+		(pixel.Y() - float32(top)) / float32(h) * c.Height,
+		0, 1,
+	}
+	world := c.View().Inv().Mul4x1(screen)
+	return mgl32.Vec2{world.X(), world.Y()}
+}
@@ -0,0 +1,84 @@
+package eng
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestCameraResize(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		viewport      [4]int32
+	}{
+		{"exact", 800, 600, [4]int32{0, 0, 800, 600}},
+		{"exact larger", 1600, 1200, [4]int32{0, 0, 1600, 1200}},
+		{"wide", 1920, 1080, [4]int32{240, 0, 1440, 1080}},
+		{"tall", 600, 1000, [4]int32{0, 275, 600, 450}},
+		{"odd", 801, 601, [4]int32{0, 0, 801, 600}},
+		{"minimized", 0, 0, [4]int32{0, 0, 800, 600}},
+	}
+	for _, test := range tests {
+		c := NewCamera2D(800, 600)
+		c.Resize(test.width, test.height)
+		x, y, w, h := c.Viewport()
+		if got := [4]int32{x, y, w, h}; got != test.viewport {
+			t.Errorf("%s: %dx%d viewport %v, want %v", test.name, test.width, test.height, got, test.viewport)
+		}
+	}
+}
+
+// project is where a point in the world is drawn in the framebuffer, in
+// pixels from its top left.
+func project(c *Camera2D, world mgl32.Vec2, framebufferH int) mgl32.Vec2 {
+	clip := c.Projection().Mul4x1(mgl32.Vec4{world.X(), world.Y(), 0, 1})
+	x, y, w, h := c.Viewport()
+	top := float32(int32(framebufferH) - y - h)
+	return mgl32.Vec2{
+		float32(x) + (clip.X()+1)/2*float32(w),
+		top + (1-clip.Y())/2*float32(h),
+	}
+}
+
+func TestCameraUnproject(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		position      mgl32.Vec2
+		zoom, rotate  float32
+	}{
+		{"at rest", 800, 600, mgl32.Vec2{}, 1, 0},
+		{"wide", 1920, 1080, mgl32.Vec2{}, 1, 0},
+		{"tall", 600, 1000, mgl32.Vec2{}, 1, 0},
+		{"moved", 1920, 1080, mgl32.Vec2{-40, 25}, 1, 0},
+		{"zoomed", 600, 1000, mgl32.Vec2{10, 10}, 2.5, 0},
+		{"rotated", 1024, 768, mgl32.Vec2{}, 1, .3},
+		{"all of it", 1920, 1200, mgl32.Vec2{100, -50}, .75, -1.2},
+	}
+	points := []mgl32.Vec2{{0, 0}, {400, 300}, {800, 600}, {123, 456}, {-50, 700}}
+	for _, test := range tests {
+		c := NewCamera2D(800, 600)
+		c.Resize(test.width, test.height)
+		c.Position, c.Zoom, c.Rotation = test.position, test.zoom, test.rotate
+		for _, world := range points {
+			pixel := project(c, world, test.height)
+			if got := c.Unproject(pixel); !got.ApproxEqualThreshold(world, 1e-2) {
+				t.Errorf("%s: %v drawn at %v unprojects to %v", test.name, world, pixel, got)
+			}
+		}
+	}
+
+	// at rest the corners of the letterbox are the corners of the world
+	c := NewCamera2D(800, 600)
+	c.Resize(1920, 1080)
+	for _, corner := range []struct{ pixel, world mgl32.Vec2 }{
+		{mgl32.Vec2{240, 0}, mgl32.Vec2{0, 0}},
+		{mgl32.Vec2{1680, 1080}, mgl32.Vec2{800, 600}},
+		{mgl32.Vec2{960, 540}, mgl32.Vec2{400, 300}},
+	} {
+		if got := c.Unproject(corner.pixel); !got.ApproxEqualThreshold(corner.world, 1e-3) {
+			t.Errorf("pixel %v unprojects to %v, want %v", corner.pixel, got, corner.world)
+		}
+	}
+}
@@ -1,44 +1,58 @@
 package eng
 
 import (
-	"fmt"
 	"image"
 	"image/draw"
-	"io/ioutil"
-	"os"
+	"io/fs"
+	"log"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
-	"github.com/golang/freetype"
 	"github.com/golang/freetype/truetype"
 	"golang.org/x/image/font"
 	"golang.org/x/image/math/fixed"
 )
 
+// atlasSize is the width and height of each glyph atlas page.
+const atlasSize = 512
+
 type TextRenderer struct {
 	shader   *Shader
 	vao, vbo uint32
-	fontChar []character
-	texture  uint32 // Holds the glyph texture id.
+
+	face   font.Face
+	glyphs map[rune]*character
+	pages  []*atlasPage
 }
 
+// character is a glyph rasterized into an atlas page. Sizes are in pixels.
 type character struct {
-	textureID uint32 // ID handle of the glyph texture
-	width     int    //glyph width
-	height    int    //glyph height
-	advance   int    //glyph advance
-	bearingH  int    //glyph bearing horizontal
-	bearingV  int    //glyph bearing vertical
+	page           int
+	u0, v0, u1, v1 float32 // texture coordinates within the page
+	width          int     //glyph width
+	height         int     //glyph height
+	advance        fixed.Int26_6
+	bearingH       int //glyph left edge from the pen position
+	bearingV       int //glyph top edge from the baseline, negative is up
 }
 
-func NewTextRenderer(shader *Shader, width, height float32, font string, scale uint32) *TextRenderer {
+// atlasPage is one texture that glyphs are packed into, a row at a time.
+type atlasPage struct {
+	texture   uint32
+	x, y      int
+	rowHeight int
+	vertices  []float32
+}
+
+// NewTextRenderer creates a renderer for a width by height screen, drawing the
+// font file in fsys at scale pixels.
+func NewTextRenderer(shader *Shader, width, height float32, fsys fs.FS, font string, scale uint32) (*TextRenderer, error) {
 	shader.Use().SetMat4("projection", mgl32.Ortho2D(0, width, height, 0)).SetInt("text", 0)
 	var VAO, VBO uint32
 	gl.GenVertexArrays(1, &VAO)
 	gl.GenBuffers(1, &VBO)
 	gl.BindVertexArray(VAO)
 	gl.BindBuffer(gl.ARRAY_BUFFER, VBO)
-	gl.BufferData(gl.ARRAY_BUFFER, 6*4*4, nil, gl.DYNAMIC_DRAW)
 
 	gl.EnableVertexAttribArray(0)
 	gl.VertexAttribPointer(0, 4, gl.FLOAT, false, 4*4, gl.PtrOffset(0))
@@ -49,165 +63,251 @@ func NewTextRenderer(shader *Shader, width, height float32, font string, scale u
 		vbo:    VBO,
 		shader: shader,
 	}
-	if err := r.Load(font, scale); err != nil {
-		panic(err)
+	if err := r.Load(fsys, font, scale); err != nil {
+		gl.DeleteVertexArrays(1, &VAO)
+		gl.DeleteBuffers(1, &VBO)
+		return nil, err
 	}
-	return r
+	return r, nil
 }
 
-func (t *TextRenderer) Load(fontPath string, scale uint32) error {
-	fd, err := os.Open(fontPath)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = fd.Close() }()
-
-	low := rune(32)
-	high := rune(127)
+// SetProjection sets the projection text is drawn through, replacing the
+// one for the screen size the renderer was created with.
+func (t *TextRenderer) SetProjection(projection mgl32.Mat4) {
+	t.shader.Use().SetMat4("projection", projection)
+}
 
-	data, err := ioutil.ReadAll(fd)
+// Load switches to the font at fontPath in fsys. Glyphs are rasterized the
+// first time they are printed, so any rune the font has can be drawn.
+func (t *TextRenderer) Load(fsys fs.FS, fontPath string, scale uint32) error {
+	data, err := fs.ReadFile(fsys, fontPath)
 	if err != nil {
-		return err
+		return &LoadError{Path: fontPath, Err: err}
 	}
 
 	ttf, err := truetype.Parse(data)
 	if err != nil {
-		return err
+		return &LoadError{Path: fontPath, Err: err}
 	}
 
-	t.fontChar = make([]character, 0, high-low+1)
+	t.resetAtlas()
+	t.face = truetype.NewFace(ttf, &truetype.Options{
+		Size:    float64(scale),
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	t.glyphs = map[rune]*character{}
 	t.SetColor(1.0, 1.0, 1.0, 1.0)
+	return nil
+}
 
-	for ch := low; ch <= high; ch++ {
-		var char character
+// glyph returns the character for r, rasterizing it into the atlas on first
+// use.
+func (t *TextRenderer) glyph(r rune) *character {
+	if ch, ok := t.glyphs[r]; ok {
+		return ch
+	}
 
-		ttfFace := truetype.NewFace(ttf, &truetype.Options{
-			Size:    float64(scale),
-			DPI:     72,
-			Hinting: font.HintingFull,
-		})
+	dr, mask, maskp, advance, ok := t.face.Glyph(fixed.Point26_6{}, r)
+	if !ok {
+		// the face has nothing for r, not even a missing glyph box
+		ch := &character{page: -1}
+		t.glyphs[r] = ch
+		return ch
+	}
 
-		gBnd, gAdv, ok := ttfFace.GlyphBounds(ch)
-		if ok != true {
-			return fmt.Errorf("ttf face glyphBounds error")
-		}
+	ch := &character{
+		width:    dr.Dx(),
+		height:   dr.Dy(),
+		advance:  advance,
+		bearingH: dr.Min.X,
+		bearingV: dr.Min.Y,
+	}
+	t.glyphs[r] = ch
+	if ch.width == 0 || ch.height == 0 {
+		ch.page = -1
+		return ch
+	}
+
+	// pad by a pixel so linear filtering doesn't bleed in the neighbours
+	w, h := ch.width+1, ch.height+1
+	if w > atlasSize || h > atlasSize {
+		// too big for any page, so it is left out like a blank glyph
+		log.Printf("glyph %q is %dx%d, too big for the %dx%d atlas", r, ch.width, ch.height, atlasSize, atlasSize)
+		ch.page = -1
+		return ch
+	}
+	page := t.pageFor(w, h)
+	ch.page = len(t.pages) - 1
+	x, y := page.x, page.y
+	page.x += w
+	if h > page.rowHeight {
+		page.rowHeight = h
+	}
 
-		gh := int32((gBnd.Max.Y - gBnd.Min.Y) >> 6)
-		gw := int32((gBnd.Max.X - gBnd.Min.X) >> 6)
+	pixels := image.NewAlpha(image.Rect(0, 0, ch.width, ch.height))
+	draw.Draw(pixels, pixels.Bounds(), mask, maskp, draw.Src)
+	gl.BindTexture(gl.TEXTURE_2D, page.texture)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexSubImage2D(gl.TEXTURE_2D, 0, int32(x), int32(y), int32(ch.width), int32(ch.height), gl.RED, gl.UNSIGNED_BYTE, gl.Ptr(pixels.Pix))
+	gl.BindTexture(gl.TEXTURE_2D, 0)
 
-		if gw == 0 || gh == 0 {
-			gBnd = ttf.Bounds(fixed.Int26_6(scale))
-			gw = int32((gBnd.Max.X - gBnd.Min.X) >> 6)
-			gh = int32((gBnd.Max.Y - gBnd.Min.Y) >> 6)
+	ch.u0 = float32(x) / atlasSize
+	ch.v0 = float32(y) / atlasSize
+	ch.u1 = float32(x+ch.width) / atlasSize
+	ch.v1 = float32(y+ch.height) / atlasSize
+	return ch
+}
 
-			if gw == 0 || gh == 0 {
-				gw = 1
-				gh = 1
-			}
+// pageFor returns the last atlas page, moved on to a fresh row or a new page
+// if a w by h glyph doesn't fit where it is. The glyph must fit on an empty
+// page.
+func (t *TextRenderer) pageFor(w, h int) *atlasPage {
+	if len(t.pages) > 0 {
+		page := t.pages[len(t.pages)-1]
+		if page.x+w > atlasSize {
+			page.x = 0
+			page.y += page.rowHeight
+			page.rowHeight = 0
 		}
-
-		gAscent := int(-gBnd.Min.Y) >> 6
-		gdescent := int(gBnd.Max.Y) >> 6
-
-		char.width = int(gw)
-		char.height = int(gh)
-		char.advance = int(gAdv)
-		char.bearingV = gdescent
-		char.bearingH = int(gBnd.Min.X) >> 6
-
-		fg, bg := image.White, image.Black
-		rect := image.Rect(0, 0, int(gw), int(gh))
-		rgba := image.NewRGBA(rect)
-		draw.Draw(rgba, rgba.Bounds(), bg, image.Point{}, draw.Src)
-
-		c := freetype.NewContext()
-		c.SetDPI(72)
-		c.SetFont(ttf)
-		c.SetFontSize(float64(scale))
-		c.SetClip(rgba.Bounds())
-		c.SetDst(rgba)
-		c.SetSrc(fg)
-		c.SetHinting(font.HintingFull)
-
-		px := 0 - (int(gBnd.Min.X) >> 6)
-		py := gAscent
-		pt := freetype.Pt(px, py)
-
-		_, err = c.DrawString(string(ch), pt)
-		if err != nil {
-			return err
+		if page.y+h <= atlasSize {
+			return page
 		}
-
-		var texture uint32
-		gl.GenTextures(1, &texture)
-		gl.BindTexture(gl.TEXTURE_2D, texture)
-		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(rgba.Rect.Dx()), int32(rgba.Rect.Dy()), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-
-		char.textureID = texture
-
-		t.fontChar = append(t.fontChar, char)
 	}
+
+	page := &atlasPage{}
+	blank := make([]uint8, atlasSize*atlasSize)
+	gl.GenTextures(1, &page.texture)
+	gl.BindTexture(gl.TEXTURE_2D, page.texture)
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RED, atlasSize, atlasSize, 0, gl.RED, gl.UNSIGNED_BYTE, gl.Ptr(blank))
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
 	gl.BindTexture(gl.TEXTURE_2D, 0)
-	return nil
+	t.pages = append(t.pages, page)
+	return page
 }
 
-//SetColor allows you to set the text color to be used when you draw the text
+// SetColor allows you to set the text color to be used when you draw the text
 func (t *TextRenderer) SetColor(red float32, green float32, blue float32, alpha float32) {
 	t.shader.Use().SetVec4f("textColor", mgl32.Vec4{red, green, blue, alpha})
 }
 
-//Printf draws a string to the screen, takes a list of arguments like printf
+// Print draws a string to the screen with its baseline starting at x, y
 func (t *TextRenderer) Print(text string, x, y float32, scale float32) {
-	indices := []rune(text)
-	if len(indices) == 0 {
-		return
-	}
-	t.shader.Use()
+	t.PrintOptions(text, x, y, TextOptions{Scale: scale})
+}
 
-	lowChar := rune(32)
+// PrintOptions draws text with its first baseline at y, aligned to x as opts
+// says.
+func (t *TextRenderer) PrintOptions(text string, x, y float32, opts TextOptions) {
+	t.DrawLayout(t.Layout(text, opts), x, y, opts.Scale)
+}
 
-	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindVertexArray(t.vao)
+// Measure returns the size of text as Print would draw it, and how far below
+// the top its first baseline is.
+func (t *TextRenderer) Measure(text string, scale float32) (width, height, baseline float32) {
+	l := t.Layout(text, TextOptions{Scale: scale})
+	return l.Width, l.Height, l.Baseline
+}
 
-	for i := range indices {
-		runeIndex := indices[i]
+// Layout lays text out with the loaded font without drawing it.
+func (t *TextRenderer) Layout(text string, opts TextOptions) TextLayout {
+	return LayoutText(faceMetrics{t.face}, text, opts)
+}
+
+// DrawLayout draws text laid out at the given scale, with its first baseline
+// at y.
+func (t *TextRenderer) DrawLayout(layout TextLayout, x, y float32, scale float32) {
+	if len(layout.Glyphs) == 0 {
+		return
+	}
+	if scale == 0 {
+		scale = 1
+	}
 
-		if int(runeIndex)-int(lowChar) > len(t.fontChar) || runeIndex < lowChar {
+	for _, g := range layout.Glyphs {
+		ch := t.glyph(g.Rune)
+		if ch.page < 0 {
 			continue
 		}
-
-		ch := t.fontChar[runeIndex-lowChar]
-
-		xpos := x + float32(ch.bearingH)*scale
-		ypos := y - float32(ch.height-ch.bearingV)*scale
+		xpos := x + g.X + float32(ch.bearingH)*scale
+		ypos := y + g.Y + float32(ch.bearingV)*scale
 		w := float32(ch.width) * scale
 		h := float32(ch.height) * scale
 
-		var vertices = []float32{
-			xpos, ypos + h, 0.0, 1.0,
-			xpos + w, ypos, 1.0, 0.0,
-			xpos, ypos, 0.0, 0.0,
-			xpos, ypos + h, 0.0, 1.0,
-			xpos + w, ypos + h, 1.0, 1.0,
-			xpos + w, ypos, 1.0, 0.0,
-		}
-
-		gl.BindTexture(gl.TEXTURE_2D, ch.textureID)
-		gl.BindBuffer(gl.ARRAY_BUFFER, t.vbo)
-		gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(vertices)*4, gl.Ptr(vertices))
-
-		gl.BindBuffer(gl.ARRAY_BUFFER, 0)
-		gl.DrawArrays(gl.TRIANGLES, 0, 6)
+		page := t.pages[ch.page]
+		page.vertices = append(page.vertices,
+			xpos, ypos+h, ch.u0, ch.v1,
+			xpos+w, ypos, ch.u1, ch.v0,
+			xpos, ypos, ch.u0, ch.v0,
+			xpos, ypos+h, ch.u0, ch.v1,
+			xpos+w, ypos+h, ch.u1, ch.v1,
+			xpos+w, ypos, ch.u1, ch.v0,
+		)
+	}
 
-		x += float32(ch.advance>>6) * scale
+	t.shader.Use()
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindVertexArray(t.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, t.vbo)
+	for _, page := range t.pages {
+		if len(page.vertices) == 0 {
+			continue
+		}
+		gl.BindTexture(gl.TEXTURE_2D, page.texture)
+		gl.BufferData(gl.ARRAY_BUFFER, len(page.vertices)*4, gl.Ptr(page.vertices), gl.STREAM_DRAW)
+		gl.DrawArrays(gl.TRIANGLES, 0, int32(len(page.vertices)/4))
+		page.vertices = page.vertices[:0]
 	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
 	gl.BindVertexArray(0)
 	gl.BindTexture(gl.TEXTURE_2D, 0)
 	gl.UseProgram(0)
-	return
 }
 
+// resetAtlas frees the atlas pages, e.g. for another font. Glyphs are
+// rasterized again when next used.
+func (t *TextRenderer) resetAtlas() {
+	for _, page := range t.pages {
+		gl.DeleteTextures(1, &page.texture)
+	}
+	t.pages = nil
+	t.glyphs = map[rune]*character{}
+}
+
+// Destroy frees the atlas pages and the buffers text is drawn from. The
+// renderer can't be used after.
+func (t *TextRenderer) Destroy() {
+	t.resetAtlas()
+	gl.DeleteVertexArrays(1, &t.vao)
+	gl.DeleteBuffers(1, &t.vbo)
+}
+
+// faceMetrics measures a font.Face for LayoutText.
+type faceMetrics struct {
+	face font.Face
+}
+
+func (f faceMetrics) Advance(r rune) float32 {
+	advance, _ := f.face.GlyphAdvance(r)
+	return float32(advance) / 64
+}
+
+func (f faceMetrics) Kern(a, b rune) float32 {
+	return float32(f.face.Kern(a, b)) / 64
+}
+
+func (f faceMetrics) Ascent() float32 {
+	return float32(f.face.Metrics().Ascent) / 64
+}
+
+func (f faceMetrics) Descent() float32 {
+	return float32(f.face.Metrics().Descent) / 64
+}
+
+func (f faceMetrics) LineHeight() float32 {
+	return float32(f.face.Metrics().Height) / 64
+}
@@ -0,0 +1,123 @@
+package eng
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// OpenFS opens a directory or a zip archive of assets. The returned Closer
+// releases the archive, and does nothing for a directory.
+func OpenFS(path string) (fs.FS, io.Closer, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		return os.DirFS(path), nopCloser{}, nil
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".zip") {
+		return nil, nil, &LoadError{Path: path, Err: errors.New("not a directory or zip archive")}
+	}
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, &LoadError{Path: path, Err: err}
+	}
+	return archive, archive, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// overlayFS looks for files in each layer in turn.
+type overlayFS []fs.FS
+
+// OverlayFS combines file systems so that files in earlier layers hide the
+// same paths in later ones, e.g. a mod directory over the default assets.
+// Directory listings are merged.
+func OverlayFS(layers ...fs.FS) fs.FS {
+	return overlayFS(layers)
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, layer := range o {
+		f, err := layer.Open(name)
+		if err == nil {
+			if info, err := f.Stat(); err == nil && info.IsDir() {
+				return &overlayDir{File: f, fsys: o, name: name}, nil
+			}
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	seen := map[string]bool{}
+	var entries []fs.DirEntry
+	found := false
+	for _, layer := range o {
+		layerEntries, err := fs.ReadDir(layer, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		found = true
+		for _, entry := range layerEntries {
+			if !seen[entry.Name()] {
+				seen[entry.Name()] = true
+				entries = append(entries, entry)
+			}
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// overlayDir is a directory opened from the first layer that has it, listing
+// what every layer has in it.
+type overlayDir struct {
+	fs.File
+	fsys    overlayFS
+	name    string
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (d *overlayDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.fsys.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries, d.read = entries, true
+	}
+	if n > 0 && len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n <= 0 || n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
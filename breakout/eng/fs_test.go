@@ -0,0 +1,170 @@
+package eng
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// overlay is a mod over the default assets.
+func overlay() fs.FS {
+	mod := fstest.MapFS{
+		"levels/1.txt":        {Data: []byte("mod")},
+		"levels/9.txt":        {Data: []byte("mod only")},
+		"textures/ball.png":   {Data: []byte("mod ball")},
+		"shaders/sprite.vs":   {Data: []byte("mod vertex")},
+		"shaders/extra/a.txt": {Data: []byte("nested")},
+	}
+	assets := fstest.MapFS{
+		"levels/1.txt":      {Data: []byte("default")},
+		"levels/2.txt":      {Data: []byte("default only")},
+		"textures/ball.png": {Data: []byte("default ball")},
+		"fonts/font.ttf":    {Data: []byte("font")},
+	}
+	return OverlayFS(mod, assets)
+}
+
+func TestOverlayFSOpen(t *testing.T) {
+	fsys := overlay()
+	tests := []struct {
+		name string
+		want string
+		err  error
+	}{
+		{"levels/1.txt", "mod", nil},
+		{"textures/ball.png", "mod ball", nil},
+		{"levels/9.txt", "mod only", nil},
+		{"levels/2.txt", "default only", nil},
+		{"fonts/font.ttf", "font", nil},
+		{"levels/3.txt", "", fs.ErrNotExist},
+		{"../levels/1.txt", "", fs.ErrInvalid},
+		{"/levels/1.txt", "", fs.ErrInvalid},
+	}
+	for _, test := range tests {
+		data, err := fs.ReadFile(fsys, test.name)
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("ReadFile(%q) error %v, want %v", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil || string(data) != test.want {
+			t.Errorf("ReadFile(%q) = %q, %v, want %q", test.name, data, err, test.want)
+		}
+	}
+}
+
+func TestOverlayFSReadDir(t *testing.T) {
+	fsys := overlay()
+	tests := []struct {
+		dir  string
+		want string
+		err  error
+	}{
+		{"levels", "[1.txt 2.txt 9.txt]", nil},
+		{"textures", "[ball.png]", nil},
+		{"fonts", "[font.ttf]", nil},
+		{"shaders", "[extra sprite.vs]", nil},
+		{".", "[fonts levels shaders textures]", nil},
+		{"sounds", "", fs.ErrNotExist},
+	}
+	for _, test := range tests {
+		entries, err := fs.ReadDir(fsys, test.dir)
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("ReadDir(%q) error %v, want %v", test.dir, err, test.err)
+			}
+			continue
+		}
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name()
+		}
+		if err != nil || fmt.Sprint(names) != test.want {
+			t.Errorf("ReadDir(%q) = %v, %v, want %s", test.dir, names, err, test.want)
+		}
+	}
+
+	if err := fstest.TestFS(fsys, "levels/1.txt", "levels/2.txt", "levels/9.txt", "fonts/font.ttf", "shaders/extra/a.txt"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOpenFS(t *testing.T) {
+	dir := t.TempDir()
+	assets := filepath.Join(dir, "assets")
+	if err := os.MkdirAll(filepath.Join(assets, "levels"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(assets, "levels", "1.txt"), []byte("from dir"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(dir, "mod.ZIP")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	entry, err := w.Create("levels/1.txt")
+	if err == nil {
+		_, err = entry.Write([]byte("from zip"))
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notArchive := filepath.Join(dir, "notes.txt")
+	if err := ioutil.WriteFile(notArchive, []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	badArchive := filepath.Join(dir, "bad.zip")
+	if err := ioutil.WriteFile(badArchive, []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, path string
+		want       string
+		loadErr    bool
+	}{
+		{"directory", assets, "from dir", false},
+		{"zip", archive, "from zip", false},
+		{"missing", filepath.Join(dir, "missing"), "", false},
+		{"not an archive", notArchive, "", true},
+		{"bad archive", badArchive, "", true},
+	}
+	for _, test := range tests {
+		fsys, closer, err := OpenFS(test.path)
+		if test.want == "" {
+			var loadErr *LoadError
+			if err == nil || errors.As(err, &loadErr) != test.loadErr {
+				t.Errorf("%s: error %v, want a load error %v", test.name, err, test.loadErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		// a mod over the embedded assets
+		data, err := fs.ReadFile(OverlayFS(fsys, fstest.MapFS{"levels/2.txt": {Data: []byte("embedded")}}), "levels/1.txt")
+		if err != nil || string(data) != test.want {
+			t.Errorf("%s: read %q, %v, want %q", test.name, data, err, test.want)
+		}
+		if err := closer.Close(); err != nil {
+			t.Errorf("%s: close: %v", test.name, err)
+		}
+	}
+}
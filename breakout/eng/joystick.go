@@ -0,0 +1,35 @@
+package eng
+
+import (
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/jakecoffman/learnopengl/breakout/input"
+)
+
+// JoystickInput is what PollJoystick reads a joystick into, such as an
+// input.Input.
+type JoystickInput interface {
+	SetJoystickButton(button int, down bool)
+	SetJoystickAxis(axis int, value float32)
+}
+
+// PollJoystick reads joy's buttons and axes into in, as glfw has no
+// callbacks for them. One that isn't connected reads as centered with
+// nothing held.
+func PollJoystick(in JoystickInput, joy glfw.Joystick) {
+	var axes []float32
+	var buttons []byte
+	if glfw.JoystickPresent(joy) {
+		axes = glfw.GetJoystickAxes(joy)
+		buttons = glfw.GetJoystickButtons(joy)
+	}
+	for i := 0; i < input.MaxJoystickAxes; i++ {
+		var value float32
+		if i < len(axes) {
+			value = axes[i]
+		}
+		in.SetJoystickAxis(i, value)
+	}
+	for i := 0; i < input.MaxJoystickButtons; i++ {
+		in.SetJoystickButton(i, i < len(buttons) && glfw.Action(buttons[i]) == glfw.Press)
+	}
+}
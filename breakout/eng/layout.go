@@ -0,0 +1,160 @@
+package eng
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Align says where lines of text sit relative to the x they are printed at.
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// FontMetrics is what text layout needs to know about a font, in unscaled
+// pixels. It lets layout run without a GL context.
+type FontMetrics interface {
+	// Advance is how far the pen moves after drawing r.
+	Advance(r rune) float32
+	// Kern is the adjustment to the advance between a and b.
+	Kern(a, b rune) float32
+	// Ascent is the height above the baseline, Descent the depth below it.
+	Ascent() float32
+	Descent() float32
+	// LineHeight is the distance between consecutive baselines.
+	LineHeight() float32
+}
+
+// TextOptions controls how text is laid out.
+type TextOptions struct {
+	// Scale multiplies the size the font was loaded at. Zero means 1.
+	Scale float32
+	Align Align
+	// MaxWidth wraps lines at spaces so they fit, breaking words that don't
+	// fit on a line of their own. Zero disables wrapping.
+	MaxWidth float32
+	// LineSpacing multiplies the font's line height. Zero means 1.
+	LineSpacing float32
+}
+
+// GlyphPosition is where the pen is when drawing Rune, relative to the x and
+// the first baseline the text is printed at.
+type GlyphPosition struct {
+	Rune rune
+	X, Y float32
+}
+
+// TextLayout is text broken into lines and positioned glyph by glyph.
+type TextLayout struct {
+	Glyphs []GlyphPosition
+	// Lines holds the width of every line.
+	Lines []float32
+	// Width is the widest line, Height spans the top of the first line to
+	// the bottom of the last, and Baseline is the first baseline from the top.
+	Width, Height, Baseline float32
+}
+
+// LayoutText lays out text, which may contain newlines, with the given
+// metrics.
+func LayoutText(m FontMetrics, text string, opts TextOptions) TextLayout {
+	scale := opts.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	spacing := opts.LineSpacing
+	if spacing == 0 {
+		spacing = 1
+	}
+	maxWidth := opts.MaxWidth / scale
+
+	var lines [][]rune
+	for _, paragraph := range strings.Split(text, "\n") {
+		if maxWidth > 0 {
+			lines = append(lines, wrapLine(m, paragraph, maxWidth)...)
+		} else {
+			lines = append(lines, []rune(paragraph))
+		}
+	}
+
+	layout := TextLayout{
+		Lines:    make([]float32, len(lines)),
+		Baseline: m.Ascent() * scale,
+	}
+	lineHeight := m.LineHeight() * spacing * scale
+	for i, line := range lines {
+		width := lineWidth(m, line) * scale
+		layout.Lines[i] = width
+		if width > layout.Width {
+			layout.Width = width
+		}
+
+		var x float32
+		switch opts.Align {
+		case AlignCenter:
+			x = -width / 2
+		case AlignRight:
+			x = -width
+		}
+		y := float32(i) * lineHeight
+		for j, r := range line {
+			if j > 0 {
+				x += m.Kern(line[j-1], r) * scale
+			}
+			layout.Glyphs = append(layout.Glyphs, GlyphPosition{Rune: r, X: x, Y: y})
+			x += m.Advance(r) * scale
+		}
+	}
+	layout.Height = (m.Ascent()+m.Descent())*scale + float32(len(lines)-1)*lineHeight
+	return layout
+}
+
+// lineWidth is the unscaled width of line including kerning.
+func lineWidth(m FontMetrics, line []rune) float32 {
+	var width float32
+	for i, r := range line {
+		if i > 0 {
+			width += m.Kern(line[i-1], r)
+		}
+		width += m.Advance(r)
+	}
+	return width
+}
+
+// wrapLine breaks text into lines no wider than maxWidth. Runs of spaces
+// between words become one space, or a line break.
+func wrapLine(m FontMetrics, text string, maxWidth float32) [][]rune {
+	var lines [][]rune
+	var line []rune
+	for _, word := range strings.FieldsFunc(text, unicode.IsSpace) {
+		w := []rune(word)
+		if len(line) > 0 {
+			candidate := append(append(line[:len(line):len(line)], ' '), w...)
+			if lineWidth(m, candidate) <= maxWidth {
+				line = candidate
+				continue
+			}
+			lines = append(lines, line)
+			line = nil
+		}
+		// the word starts a line, so it may have to be broken up
+		for len(w) > 0 {
+			n := 1
+			for n < len(w) && lineWidth(m, w[:n+1]) <= maxWidth {
+				n++
+			}
+			if n == len(w) {
+				line = w
+				break
+			}
+			lines = append(lines, w[:n])
+			w = w[n:]
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
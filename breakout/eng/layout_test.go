@@ -0,0 +1,136 @@
+package eng
+
+import (
+	"reflect"
+	"testing"
+)
+
+// fakeMetrics is a monospaced font 10 wide, with a narrow i and A and V
+// kerned together.
+type fakeMetrics struct{}
+
+func (fakeMetrics) Advance(r rune) float32 {
+	if r == 'i' {
+		return 4
+	}
+	return 10
+}
+
+func (fakeMetrics) Kern(a, b rune) float32 {
+	if a == 'A' && b == 'V' {
+		return -3
+	}
+	return 0
+}
+
+func (fakeMetrics) Ascent() float32     { return 8 }
+func (fakeMetrics) Descent() float32    { return 2 }
+func (fakeMetrics) LineHeight() float32 { return 12 }
+
+// lineText is the text of each line of l, found from where its glyphs are.
+func lineText(l TextLayout) []string {
+	var lines []string
+	var y float32
+	for i, g := range l.Glyphs {
+		if i == 0 || g.Y != y {
+			lines = append(lines, "")
+			y = g.Y
+		}
+		lines[len(lines)-1] += string(g.Rune)
+	}
+	return lines
+}
+
+func TestLayoutTextPositions(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		opts   TextOptions
+		glyphs []GlyphPosition
+		lines  []float32
+	}{
+		{"plain", "AB", TextOptions{}, []GlyphPosition{{'A', 0, 0}, {'B', 10, 0}}, []float32{20}},
+		{"narrow", "iB", TextOptions{}, []GlyphPosition{{'i', 0, 0}, {'B', 4, 0}}, []float32{14}},
+		{"kerned", "AVA", TextOptions{}, []GlyphPosition{{'A', 0, 0}, {'V', 7, 0}, {'A', 17, 0}}, []float32{27}},
+		{"kerned only one way", "VA", TextOptions{}, []GlyphPosition{{'V', 0, 0}, {'A', 10, 0}}, []float32{20}},
+		{"scaled", "AV", TextOptions{Scale: 2}, []GlyphPosition{{'A', 0, 0}, {'V', 14, 0}}, []float32{34}},
+		{"centered", "AB", TextOptions{Align: AlignCenter}, []GlyphPosition{{'A', -10, 0}, {'B', 0, 0}}, []float32{20}},
+		{"right", "AB", TextOptions{Align: AlignRight}, []GlyphPosition{{'A', -20, 0}, {'B', -10, 0}}, []float32{20}},
+		{"right kerned", "AV", TextOptions{Align: AlignRight}, []GlyphPosition{{'A', -17, 0}, {'V', -10, 0}}, []float32{17}},
+		{"lines centered", "A\nBC", TextOptions{Align: AlignCenter},
+			[]GlyphPosition{{'A', -5, 0}, {'B', -10, 12}, {'C', 0, 12}}, []float32{10, 20}},
+		{"line spacing", "A\nB", TextOptions{LineSpacing: 1.5}, []GlyphPosition{{'A', 0, 0}, {'B', 0, 18}}, []float32{10, 10}},
+		{"blank line", "A\n\nB", TextOptions{}, []GlyphPosition{{'A', 0, 0}, {'B', 0, 24}}, []float32{10, 0, 10}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := LayoutText(fakeMetrics{}, test.text, test.opts)
+			if !reflect.DeepEqual(l.Glyphs, test.glyphs) {
+				t.Errorf("glyphs = %v, want %v", l.Glyphs, test.glyphs)
+			}
+			if !reflect.DeepEqual(l.Lines, test.lines) {
+				t.Errorf("lines = %v, want %v", l.Lines, test.lines)
+			}
+		})
+	}
+}
+
+func TestLayoutTextSize(t *testing.T) {
+	tests := []struct {
+		name                    string
+		text                    string
+		opts                    TextOptions
+		width, height, baseline float32
+	}{
+		{"empty", "", TextOptions{}, 0, 10, 8},
+		{"one line", "ABC", TextOptions{}, 30, 10, 8},
+		{"widest line", "AB\nABCD\nA", TextOptions{}, 40, 10 + 2*12, 8},
+		{"scaled", "AB\nA", TextOptions{Scale: 2}, 40, 20 + 24, 16},
+		{"spaced", "A\nA", TextOptions{LineSpacing: 2}, 10, 10 + 24, 8},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := LayoutText(fakeMetrics{}, test.text, test.opts)
+			if l.Width != test.width || l.Height != test.height || l.Baseline != test.baseline {
+				t.Errorf("width %v, height %v, baseline %v, want %v, %v, %v",
+					l.Width, l.Height, l.Baseline, test.width, test.height, test.baseline)
+			}
+		})
+	}
+}
+
+func TestLayoutTextWrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		opts  TextOptions
+		lines []string
+		width []float32
+	}{
+		{"fits", "aa bb", TextOptions{MaxWidth: 50}, []string{"aa bb"}, []float32{50}},
+		{"breaks at a space", "aa bb cc", TextOptions{MaxWidth: 50}, []string{"aa bb", "cc"}, []float32{50, 20}},
+		{"spaces collapse", "aa    bb", TextOptions{MaxWidth: 100}, []string{"aa bb"}, []float32{50}},
+		{"leading and trailing spaces", "  aa  ", TextOptions{MaxWidth: 100}, []string{"aa"}, []float32{20}},
+		{"long word", "abcdefgh", TextOptions{MaxWidth: 30}, []string{"abc", "def", "gh"}, []float32{30, 30, 20}},
+		{"long word after a short one", "a bcdef", TextOptions{MaxWidth: 30}, []string{"a", "bcd", "ef"}, []float32{10, 30, 20}},
+		{"narrower than a glyph", "ab", TextOptions{MaxWidth: 5}, []string{"a", "b"}, []float32{10, 10}},
+		{"narrow glyphs fit more", "iiii iiii", TextOptions{MaxWidth: 30}, []string{"iiii", "iiii"}, []float32{16, 16}},
+		// AV AV is 44 kerned and 50 without
+		{"kerning counts", "AV AV", TextOptions{MaxWidth: 45}, []string{"AV AV"}, []float32{44}},
+		{"kerning too wide", "AV AV", TextOptions{MaxWidth: 40}, []string{"AV", "AV"}, []float32{17, 17}},
+		{"scaled", "aa bb cc", TextOptions{MaxWidth: 100, Scale: 2}, []string{"aa bb", "cc"}, []float32{100, 40}},
+		{"newlines kept", "aa\nbb cc", TextOptions{MaxWidth: 100}, []string{"aa", "bb cc"}, []float32{20, 50}},
+		{"only spaces", "   ", TextOptions{MaxWidth: 100}, nil, []float32{0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := LayoutText(fakeMetrics{}, test.text, test.opts)
+			if got := lineText(l); !reflect.DeepEqual(got, test.lines) {
+				t.Errorf("lines = %q, want %q", got, test.lines)
+			}
+			if !reflect.DeepEqual(l.Lines, test.width) {
+				t.Errorf("widths = %v, want %v", l.Lines, test.width)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package eng
+
+import "github.com/go-gl/glfw/v3.2/glfw"
+
+// Clock tells the time in seconds from some fixed point.
+type Clock interface {
+	Now() float64
+}
+
+type glfwClock struct{}
+
+func (glfwClock) Now() float64 {
+	return glfw.GetTime()
+}
+
+// GLFWClock is real time as glfw keeps it. It only works once glfw is
+// initialized, as it is inside Run.
+var GLFWClock Clock = glfwClock{}
+
+// ManualClock only moves when it is told to, to step a Loop in tests or
+// faster than real time.
+type ManualClock struct {
+	Time float64
+}
+
+func (c *ManualClock) Now() float64 {
+	return c.Time
+}
+
+// Advance moves the clock on by seconds.
+func (c *ManualClock) Advance(seconds float64) {
+	c.Time += seconds
+}
+
+// DefaultStep is the timestep a new Loop updates with, in seconds.
+const DefaultStep = 1. / 60.
+
+// Loop updates at a fixed timestep however fast frames are drawn, so updates
+// are the same for the same input whatever the frame rate. Time that doesn't
+// make a whole step carries over to the next frame.
+type Loop struct {
+	Clock Clock
+	// Step is the time every update is given, in seconds.
+	Step float64
+	// MaxFrameTime caps how much time one frame catches up on, so a stall
+	// doesn't become a burst of updates that stalls the next frame too. Zero
+	// catches up on everything.
+	MaxFrameTime float64
+	// TimeScale is how fast time passes, 1 for real time and below that for
+	// slow motion.
+	TimeScale float64
+	// Paused stops time. Single steps can still be taken with StepOnce.
+	Paused bool
+	// Ticks counts the updates run so far.
+	Ticks uint64
+
+	started     bool
+	last        float64
+	accumulator float64
+	steps       int
+}
+
+// NewLoop creates a loop reading time from clock at DefaultStep, catching up
+// on at most a quarter of a second a frame.
+func NewLoop(clock Clock) *Loop {
+	return &Loop{
+		Clock:        clock,
+		Step:         DefaultStep,
+		MaxFrameTime: .25,
+		TimeScale:    1,
+	}
+}
+
+// StepOnce runs a single update in the next Advance, e.g. to go through a
+// paused game a step at a time.
+func (l *Loop) StepOnce() {
+	l.steps++
+}
+
+// Advance reads the clock and calls update once for every step of time since
+// the last call, and for every StepOnce. The first call starts the clock.
+// It returns how far time is into the next step, from 0 to 1, to blend
+// rendering between the last two updates.
+func (l *Loop) Advance(update func(dt float32)) (alpha float32) {
+	now := l.Clock.Now()
+	if !l.started {
+		l.last, l.started = now, true
+	}
+	frameTime := now - l.last
+	l.last = now
+	if l.MaxFrameTime > 0 && frameTime > l.MaxFrameTime {
+		frameTime = l.MaxFrameTime
+	}
+	if !l.Paused {
+		l.accumulator += frameTime * l.TimeScale
+	}
+
+	for l.accumulator >= l.Step {
+		update(float32(l.Step))
+		l.Ticks++
+		l.accumulator -= l.Step
+	}
+	for ; l.steps > 0; l.steps-- {
+		update(float32(l.Step))
+		l.Ticks++
+	}
+	return float32(l.accumulator / l.Step)
+}
@@ -0,0 +1,121 @@
+package eng
+
+import "testing"
+
+// newTestLoop is a loop on a manual clock with a step of a quarter second,
+// which floats hold exactly, and no cap on frame time.
+func newTestLoop() (*Loop, *ManualClock) {
+	clock := &ManualClock{}
+	l := NewLoop(clock)
+	l.Step = .25
+	l.MaxFrameTime = 0
+	return l, clock
+}
+
+// frame advances the clock by seconds then the loop, returning how many
+// updates ran and the alpha.
+func frame(t *testing.T, l *Loop, clock *ManualClock, seconds float64) (updates int, alpha float32) {
+	t.Helper()
+	clock.Advance(seconds)
+	alpha = l.Advance(func(dt float32) {
+		if dt != float32(l.Step) {
+			t.Errorf("update given %v, want the step %v", dt, l.Step)
+		}
+		updates++
+	})
+	return updates, alpha
+}
+
+func TestLoopAccumulates(t *testing.T) {
+	tests := []struct {
+		name    string
+		frames  []float64
+		updates []int
+		alphas  []float32
+	}{
+		{"first frame starts the clock", []float64{10}, []int{0}, []float32{0}},
+		{"a step a frame", []float64{0, .25, .25}, []int{0, 1, 1}, []float32{0, 0, 0}},
+		{"several steps in a frame", []float64{0, 1}, []int{0, 4}, []float32{0, 0}},
+		{"part steps carry over", []float64{0, .125, .125, .375}, []int{0, 0, 1, 1}, []float32{0, .5, 0, .5}},
+		{"no time", []float64{0, 0}, []int{0, 0}, []float32{0, 0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l, clock := newTestLoop()
+			total := 0
+			for i, seconds := range test.frames {
+				updates, alpha := frame(t, l, clock, seconds)
+				if updates != test.updates[i] || alpha != test.alphas[i] {
+					t.Errorf("frame %d: %d updates, alpha %v, want %d, %v", i, updates, alpha, test.updates[i], test.alphas[i])
+				}
+				total += updates
+			}
+			if l.Ticks != uint64(total) {
+				t.Errorf("ticks = %d, want %d", l.Ticks, total)
+			}
+		})
+	}
+}
+
+func TestLoopMaxFrameTime(t *testing.T) {
+	l, clock := newTestLoop()
+	l.MaxFrameTime = .5
+	frame(t, l, clock, 0)
+	if updates, _ := frame(t, l, clock, 10); updates != 2 {
+		t.Errorf("%d updates after a stall, want 2 for the capped half second", updates)
+	}
+	if updates, _ := frame(t, l, clock, .25); updates != 1 {
+		t.Errorf("%d updates after the stall, want 1", updates)
+	}
+}
+
+func TestLoopTimeScale(t *testing.T) {
+	tests := []struct {
+		scale   float64
+		updates int
+	}{
+		{1, 4},
+		{.5, 2},
+		{.25, 1},
+		{2, 8},
+	}
+	for _, test := range tests {
+		l, clock := newTestLoop()
+		l.TimeScale = test.scale
+		frame(t, l, clock, 0)
+		if updates, _ := frame(t, l, clock, 1); updates != test.updates {
+			t.Errorf("time scale %v: %d updates in a second, want %d", test.scale, updates, test.updates)
+		}
+	}
+}
+
+func TestLoopStepOnce(t *testing.T) {
+	l, clock := newTestLoop()
+	l.Paused = true
+	frame(t, l, clock, 0)
+	if updates, _ := frame(t, l, clock, 1); updates != 0 {
+		t.Fatalf("%d updates while paused, want none", updates)
+	}
+
+	l.StepOnce()
+	if updates, _ := frame(t, l, clock, 1); updates != 1 {
+		t.Errorf("%d updates for one step, want 1", updates)
+	}
+	l.StepOnce()
+	l.StepOnce()
+	if updates, _ := frame(t, l, clock, 0); updates != 2 {
+		t.Errorf("%d updates for two steps, want 2", updates)
+	}
+	if updates, _ := frame(t, l, clock, 1); updates != 0 {
+		t.Errorf("%d updates after stepping, want none", updates)
+	}
+
+	// time paused over isn't caught up on
+	l.Paused = false
+	if updates, _ := frame(t, l, clock, .25); updates != 1 {
+		t.Errorf("%d updates unpaused, want 1", updates)
+	}
+	if l.Ticks != 4 {
+		t.Errorf("ticks = %d, want 4", l.Ticks)
+	}
+}
@@ -1,6 +1,7 @@
 package eng
 
 import (
+	"math"
 	"math/rand"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
@@ -9,33 +10,96 @@ import (
 
 type Particle struct {
 	Position, Velocity mgl32.Vec2
-	Color              mgl32.Vec4
-	Life               float32
+	// Brightness scales the color of this particle only
+	Brightness float32
+	// Life counts down from MaxLife to zero
+	Life, MaxLife float32
 }
 
-func NewParticle() *Particle {
-	return &Particle{
-		Color: mgl32.Vec4{1, 1, 1, 1},
-	}
+// BlendMode says how particles are blended with what is behind them.
+type BlendMode int
+
+const (
+	BlendAdditive BlendMode = iota
+	BlendAlpha
+)
+
+// EmitterConfig describes how a ParticleGenerator spawns and ages particles.
+// Ranges given as a min and max are picked from uniformly per particle.
+type EmitterConfig struct {
+	// Amount is the most particles alive at once.
+	Amount int
+	// SpawnRate is the number of particles Spawn emits per second.
+	SpawnRate float32
+
+	LifeMin, LifeMax float32
+
+	// Jitter randomly offsets where particles spawn, up to this far.
+	Jitter float32
+	// Particles leave at an Angle in radians, spread over a Cone of the
+	// same unit, at a speed between SpeedMin and SpeedMax. InheritVelocity
+	// adds that fraction of the emitter's velocity.
+	Angle, Cone        float32
+	SpeedMin, SpeedMax float32
+	InheritVelocity    float32
+	Gravity            mgl32.Vec2
+
+	// Size goes from start to end over the particle's life, and color over
+	// the first ColorTime of it, as a fraction from 0 to 1, then stays at
+	// ColorEnd. A ColorTime of 0 takes the whole life. Alpha is clamped to
+	// 0 to 1.
+	SizeStart, SizeEnd           float32
+	ColorStart, ColorEnd         mgl32.Vec4
+	ColorTime                    float32
+	BrightnessMin, BrightnessMax float32
+
+	Blend BlendMode
+}
+
+// DefaultEmitterConfig is a short lived trail left behind a moving emitter.
+var DefaultEmitterConfig = EmitterConfig{
+	Amount:          500,
+	SpawnRate:       120,
+	LifeMin:         1,
+	LifeMax:         1,
+	Jitter:          5,
+	InheritVelocity: -0.1,
+	SizeStart:       10,
+	SizeEnd:         10,
+	ColorStart:      mgl32.Vec4{1, 1, 1, 1},
+	ColorEnd:        mgl32.Vec4{1, 1, 1, 0},
+	ColorTime:       .4,
+	BrightnessMin:   .5,
+	BrightnessMax:   1.5,
+	Blend:           BlendAdditive,
 }
 
+// floats per instance: <vec2 offset, float size, vec4 color>
+const particleInstanceSize = 7
+
 type ParticleGenerator struct {
-	particles        []*Particle
+	particles        []Particle
 	lastUsedParticle int
-	Amount           int
-	Shader           *Shader
-	Texture          *Texture2D
-	VAO              uint32
+	spawnDebt        float32
+	instances        []float32
+
+	Config  EmitterConfig
+	Shader  *Shader
+	Texture *Texture2D
+	VAO     uint32
+	VBO     uint32
+	quadVBO uint32
 }
 
-func NewParticleGenerator(shader *Shader, texture *Texture2D, amount int) *ParticleGenerator {
+func NewParticleGenerator(shader *Shader, texture *Texture2D, config EmitterConfig) *ParticleGenerator {
 	particleGenerator := &ParticleGenerator{
-		Shader:  shader,
-		Texture: texture,
-		Amount:  amount,
+		Shader:    shader,
+		Texture:   texture,
+		Config:    config,
+		particles: make([]Particle, config.Amount),
+		instances: make([]float32, 0, config.Amount*particleInstanceSize),
 	}
 
-	var VBO uint32
 	particleQuad := []float32{
 		0, 1, 0, 1,
 		1, 0, 1, 0,
@@ -46,56 +110,118 @@ func NewParticleGenerator(shader *Shader, texture *Texture2D, amount int) *Parti
 		1, 0, 1, 0,
 	}
 	gl.GenVertexArrays(1, &particleGenerator.VAO)
-	gl.GenBuffers(1, &VBO)
+	gl.GenBuffers(1, &particleGenerator.quadVBO)
+	gl.GenBuffers(1, &particleGenerator.VBO)
 	gl.BindVertexArray(particleGenerator.VAO)
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, VBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, particleGenerator.quadVBO)
 	gl.BufferData(gl.ARRAY_BUFFER, len(particleQuad)*4, gl.Ptr(particleQuad), gl.STATIC_DRAW)
-
 	gl.EnableVertexAttribArray(0)
 	gl.VertexAttribPointer(0, 4, gl.FLOAT, false, 4*4, gl.PtrOffset(0))
-	gl.BindVertexArray(0)
 
-	for i := 0; i < amount; i++ {
-		particleGenerator.particles = append(particleGenerator.particles, NewParticle())
-	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, particleGenerator.VBO)
+	gl.BufferData(gl.ARRAY_BUFFER, cap(particleGenerator.instances)*4, nil, gl.STREAM_DRAW)
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(1, 2, gl.FLOAT, false, particleInstanceSize*4, gl.PtrOffset(0))
+	gl.VertexAttribDivisor(1, 1)
+	gl.EnableVertexAttribArray(2)
+	gl.VertexAttribPointer(2, 1, gl.FLOAT, false, particleInstanceSize*4, gl.PtrOffset(2*4))
+	gl.VertexAttribDivisor(2, 1)
+	gl.EnableVertexAttribArray(3)
+	gl.VertexAttribPointer(3, 4, gl.FLOAT, false, particleInstanceSize*4, gl.PtrOffset(3*4))
+	gl.VertexAttribDivisor(3, 1)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
 
 	return particleGenerator
 }
 
-func (p *ParticleGenerator) Update(dt float32, position, velocity mgl32.Vec2, newParticles int, offset mgl32.Vec2) {
-	for i := 0; i < newParticles; i++ {
-		unusedParticles := p.firstUnusedParticle()
-		p.respawnParticle(p.particles[unusedParticles], position, velocity, offset)
+// SetProjection sets the projection particles are drawn through.
+func (p *ParticleGenerator) SetProjection(projection mgl32.Mat4) {
+	p.Shader.Use().SetMat4("projection", projection)
+}
+
+// Spawn emits particles from position at the configured SpawnRate, as if
+// the emitter had been there for dt seconds.
+func (p *ParticleGenerator) Spawn(dt float32, position, velocity mgl32.Vec2) {
+	p.spawnDebt += p.Config.SpawnRate * dt
+	n := int(p.spawnDebt)
+	p.spawnDebt -= float32(n)
+	p.Emit(n, position, velocity)
+}
+
+// Emit spawns a burst of n particles at position.
+func (p *ParticleGenerator) Emit(n int, position, velocity mgl32.Vec2) {
+	if len(p.particles) == 0 {
+		return
 	}
-	for i := 0; i < p.Amount; i++ {
-		p := p.particles[i]
-		p.Life -= dt
-		if p.Life > 0 {
-			p.Position = p.Position.Sub(p.Velocity.Mul(dt))
-			p.Color = p.Color.Sub(mgl32.Vec4{0, 0, 0, dt*2.5})
+	for i := 0; i < n; i++ {
+		unusedParticle := p.firstUnusedParticle()
+		p.respawnParticle(&p.particles[unusedParticle], position, velocity)
+	}
+}
+
+// Update ages and moves every live particle.
+func (p *ParticleGenerator) Update(dt float32) {
+	for i := range p.particles {
+		particle := &p.particles[i]
+		particle.Life -= dt
+		if particle.Life > 0 {
+			particle.Velocity = particle.Velocity.Add(p.Config.Gravity.Mul(dt))
+			particle.Position = particle.Position.Add(particle.Velocity.Mul(dt))
 		}
 	}
 }
 
+// Draw draws every live particle with one instanced draw call.
 func (p *ParticleGenerator) Draw() {
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE)
-	p.Shader.Use()
+	c := &p.Config
+	p.instances = p.instances[:0]
 	for _, particle := range p.particles {
-		if particle.Life > 0 {
-			p.Shader.SetVec2f("offset", particle.Position)
-			p.Shader.SetVec4f("color", particle.Color)
-			p.Texture.Bind()
-			gl.BindVertexArray(p.VAO)
-			gl.DrawArrays(gl.TRIANGLES, 0, 6)
-			gl.BindVertexArray(0)
+		if particle.Life <= 0 {
+			continue
 		}
+		size, color := c.look(particle)
+		p.instances = append(p.instances,
+			particle.Position.X(), particle.Position.Y(), size,
+			color.X(), color.Y(), color.Z(), color.W())
+	}
+	count := len(p.instances) / particleInstanceSize
+	if count == 0 {
+		return
 	}
+
+	if c.Blend == BlendAdditive {
+		gl.BlendFunc(gl.SRC_ALPHA, gl.ONE)
+	}
+	p.Shader.Use()
+	gl.ActiveTexture(gl.TEXTURE0)
+	p.Texture.Bind()
+	gl.BindVertexArray(p.VAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, p.VBO)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(p.instances)*4, gl.Ptr(p.instances))
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.DrawArraysInstanced(gl.TRIANGLES, 0, 6, int32(count))
+	gl.BindVertexArray(0)
 	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 }
 
+// look is the size and color of a live particle at its age.
+func (c *EmitterConfig) look(particle Particle) (size float32, color mgl32.Vec4) {
+	t := 1 - particle.Life/particle.MaxLife
+	size = c.SizeStart + (c.SizeEnd-c.SizeStart)*t
+
+	fade := t
+	if c.ColorTime > 0 {
+		fade = mgl32.Clamp(t/c.ColorTime, 0, 1)
+	}
+	color = c.ColorStart.Add(c.ColorEnd.Sub(c.ColorStart).Mul(fade))
+	b := particle.Brightness
+	return size, mgl32.Vec4{color.X() * b, color.Y() * b, color.Z() * b, mgl32.Clamp(color.W(), 0, 1)}
+}
+
 func (p *ParticleGenerator) firstUnusedParticle() int {
-	for i := p.lastUsedParticle; i < p.Amount; i++ {
+	for i := p.lastUsedParticle; i < len(p.particles); i++ {
 		if p.particles[i].Life <= 0 {
 			p.lastUsedParticle = i
 			return i
@@ -111,11 +237,26 @@ func (p *ParticleGenerator) firstUnusedParticle() int {
 	return 0
 }
 
-func (p *ParticleGenerator) respawnParticle(particle *Particle, position, velocity mgl32.Vec2, offset mgl32.Vec2) {
-	random := (float32(rand.Intn(100)) - 50.0) / 10.0
-	rColor := 0.5 + (float32(rand.Intn(100)) / 100.0)
-	particle.Position = position.Add(mgl32.Vec2{offset.X() + random, offset.Y() + random})
-	particle.Color = mgl32.Vec4{rColor, rColor, rColor, 1}
-	particle.Life = 1
-	particle.Velocity = velocity.Mul(0.1)
+func (p *ParticleGenerator) respawnParticle(particle *Particle, position, velocity mgl32.Vec2) {
+	c := &p.Config
+	jitter := mgl32.Vec2{between(-c.Jitter, c.Jitter), between(-c.Jitter, c.Jitter)}
+	angle := float64(c.Angle + between(-c.Cone/2, c.Cone/2))
+	speed := between(c.SpeedMin, c.SpeedMax)
+
+	particle.Position = position.Add(jitter)
+	particle.Velocity = velocity.Mul(c.InheritVelocity).
+		Add(mgl32.Vec2{float32(math.Cos(angle)), float32(math.Sin(angle))}.Mul(speed))
+	particle.Brightness = between(c.BrightnessMin, c.BrightnessMax)
+	particle.MaxLife = between(c.LifeMin, c.LifeMax)
+	particle.Life = particle.MaxLife
+}
+
+func between(min, max float32) float32 {
+	return min + rand.Float32()*(max-min)
+}
+
+func (p *ParticleGenerator) Destroy() {
+	gl.DeleteVertexArrays(1, &p.VAO)
+	gl.DeleteBuffers(1, &p.VBO)
+	gl.DeleteBuffers(1, &p.quadVBO)
 }
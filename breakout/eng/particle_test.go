@@ -0,0 +1,52 @@
+package eng
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestParticleLook(t *testing.T) {
+	c := EmitterConfig{
+		SizeStart:  10,
+		SizeEnd:    0,
+		ColorStart: mgl32.Vec4{1, 1, 1, 1},
+		ColorEnd:   mgl32.Vec4{0, .5, 1, 0},
+		ColorTime:  .5,
+	}
+	tests := []struct {
+		name       string
+		life       float32
+		brightness float32
+		size       float32
+		color      mgl32.Vec4
+	}{
+		{"born", 1, 1, 10, mgl32.Vec4{1, 1, 1, 1}},
+		{"fading", .75, 1, 7.5, mgl32.Vec4{.5, .75, 1, .5}},
+		{"faded", .5, 1, 5, mgl32.Vec4{0, .5, 1, 0}},
+		{"past the fade", .1, 1, 1, mgl32.Vec4{0, .5, 1, 0}},
+		{"bright", 1, 1.5, 10, mgl32.Vec4{1.5, 1.5, 1.5, 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			particle := Particle{Life: test.life, MaxLife: 1, Brightness: test.brightness}
+			size, color := c.look(particle)
+			if !mgl32.FloatEqualThreshold(size, test.size, 1e-5) {
+				t.Errorf("size = %v, want %v", size, test.size)
+			}
+			if !color.ApproxEqualThreshold(test.color, 1e-5) {
+				t.Errorf("color = %v, want %v", color, test.color)
+			}
+		})
+	}
+}
+
+func TestParticleAlphaClamped(t *testing.T) {
+	c := EmitterConfig{ColorStart: mgl32.Vec4{1, 1, 1, 2}, ColorEnd: mgl32.Vec4{1, 1, 1, -1}}
+	for life := float32(1); life > 0; life -= .05 {
+		_, color := c.look(Particle{Life: life, MaxLife: 1, Brightness: 1})
+		if a := color.W(); a < 0 || a > 1 {
+			t.Fatalf("alpha %v at life %v, want 0 to 1", a, life)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package eng
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// PostProcessor renders a scene into an offscreen framebuffer and then draws
+// it to the screen through a post-processing shader. The effect flags may be
+// flipped at any time and apply to the next Render.
+type PostProcessor struct {
+	shader        *Shader
+	Texture       *Texture2D
+	Width, Height int
+	Samples       int
+
+	// msfbo is multisampled and resolved into fbo, which backs Texture. With
+	// no samples the scene is drawn into fbo directly and msfbo is unused.
+	msfbo, fbo, rbo uint32
+	vao, vbo        uint32
+	viewport        [4]int32
+
+	Confuse, Chaos, Shake, Blur, Invert bool
+}
+
+// NewPostProcessor creates a width by height offscreen target. With samples
+// above zero the scene is rendered multisampled.
+func NewPostProcessor(shader *Shader, width, height, samples int) (*PostProcessor, error) {
+	p := &PostProcessor{
+		shader:  shader,
+		Width:   width,
+		Height:  height,
+		Samples: samples,
+	}
+
+	gl.GenFramebuffers(1, &p.fbo)
+	if samples > 0 {
+		gl.GenFramebuffers(1, &p.msfbo)
+		gl.GenRenderbuffers(1, &p.rbo)
+	}
+	p.Texture = NewTexture()
+	p.Texture.InternalFormat = gl.RGB
+	p.Texture.ImageFormat = gl.RGB
+	gl.BindTexture(gl.TEXTURE_2D, p.Texture.ID)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, p.Texture.WrapS)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, p.Texture.WrapT)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, p.Texture.FilterMin)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, p.Texture.FilterMax)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	p.initRenderData()
+	if err := p.allocate(); err != nil {
+		p.Destroy()
+		return nil, err
+	}
+
+	offset := float32(1.0 / 300.0)
+	offsets := []mgl32.Vec2{
+		{-offset, offset}, {0, offset}, {offset, offset},
+		{-offset, 0}, {0, 0}, {offset, 0},
+		{-offset, -offset}, {0, -offset}, {offset, -offset},
+	}
+	edgeKernel := []int{
+		-1, -1, -1,
+		-1, 8, -1,
+		-1, -1, -1,
+	}
+	blurKernel := []float64{
+		1. / 16, 2. / 16, 1. / 16,
+		2. / 16, 4. / 16, 2. / 16,
+		1. / 16, 2. / 16, 1. / 16,
+	}
+	shader.Use().SetInt("scene", 0)
+	for i := 0; i < 9; i++ {
+		shader.SetVec2f(fmt.Sprintf("offsets[%d]", i), offsets[i])
+		shader.SetInt(fmt.Sprintf("edgeKernel[%d]", i), edgeKernel[i])
+		shader.SetFloat(fmt.Sprintf("blurKernel[%d]", i), blurKernel[i])
+	}
+	return p, nil
+}
+
+// Resize reallocates the offscreen target at a new size, e.g. to match the
+// window so the scene is rendered at its resolution. If the driver can't
+// render at that size the target is left as it was.
+func (p *PostProcessor) Resize(width, height int) error {
+	if width <= 0 || height <= 0 || width == p.Width && height == p.Height {
+		return nil
+	}
+	oldWidth, oldHeight := p.Width, p.Height
+	p.Width, p.Height = width, height
+	if err := p.allocate(); err != nil {
+		p.Width, p.Height = oldWidth, oldHeight
+		p.allocate()
+		return err
+	}
+	return nil
+}
+
+// allocate sizes the framebuffers' storage to Width by Height.
+func (p *PostProcessor) allocate() error {
+	defer gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	w, h := int32(p.Width), int32(p.Height)
+	if p.Samples > 0 {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, p.msfbo)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, p.rbo)
+		gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, int32(p.Samples), gl.RGB, w, h)
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, p.rbo)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+		if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+			return fmt.Errorf("failed to initialize %dx%d multisampled framebuffer: 0x%x", w, h, status)
+		}
+	}
+
+	p.Texture.Width, p.Texture.Height = p.Width, p.Height
+	gl.BindFramebuffer(gl.FRAMEBUFFER, p.fbo)
+	gl.BindTexture(gl.TEXTURE_2D, p.Texture.ID)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, p.Texture.InternalFormat, w, h, 0, p.Texture.ImageFormat, gl.UNSIGNED_BYTE, nil)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, p.Texture.ID, 0)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("failed to initialize %dx%d framebuffer: 0x%x", w, h, status)
+	}
+	return nil
+}
+
+func (p *PostProcessor) initRenderData() {
+	vertices := []float32{
+		-1, -1, 0, 0,
+		1, 1, 1, 1,
+		-1, 1, 0, 1,
+
+		-1, -1, 0, 0,
+		1, -1, 1, 0,
+		1, 1, 1, 1,
+	}
+
+	gl.GenVertexArrays(1, &p.vao)
+	gl.GenBuffers(1, &p.vbo)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, p.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+
+	gl.BindVertexArray(p.vao)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 4, gl.FLOAT, false, 4*4, gl.PtrOffset(0))
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
+}
+
+// Begin redirects rendering into the offscreen framebuffer.
+func (p *PostProcessor) Begin() {
+	gl.GetIntegerv(gl.VIEWPORT, &p.viewport[0])
+	if p.Samples > 0 {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, p.msfbo)
+	} else {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, p.fbo)
+	}
+	gl.Viewport(0, 0, int32(p.Width), int32(p.Height))
+	gl.ClearColor(0, 0, 0, 1)
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+}
+
+// End resolves the scene into Texture and goes back to the screen.
+func (p *PostProcessor) End() {
+	if p.Samples > 0 {
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, p.msfbo)
+		gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, p.fbo)
+		w, h := int32(p.Width), int32(p.Height)
+		gl.BlitFramebuffer(0, 0, w, h, 0, 0, w, h, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(p.viewport[0], p.viewport[1], p.viewport[2], p.viewport[3])
+}
+
+// Render draws the processed scene to the screen. time drives the animated
+// effects.
+func (p *PostProcessor) Render(time float64) {
+	p.shader.Use().
+		SetFloat("time", time).
+		SetBool("confuse", p.Confuse).
+		SetBool("chaos", p.Chaos).
+		SetBool("shake", p.Shake).
+		SetBool("blur", p.Blur).
+		SetBool("invert", p.Invert)
+
+	gl.ActiveTexture(gl.TEXTURE0)
+	p.Texture.Bind()
+	gl.BindVertexArray(p.vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, 6)
+	gl.BindVertexArray(0)
+}
+
+// ReadPixels reads back what was last drawn to the screen, e.g. to capture
+// the output of Render in a test.
+func (p *PostProcessor) ReadPixels() *image.RGBA {
+	x, y, w, h := p.viewport[0], p.viewport[1], int(p.viewport[2]), int(p.viewport[3])
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(x, y, int32(w), int32(h), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+
+	// GL's origin is the bottom left, images start at the top
+	stride := img.Stride
+	row := make([]byte, stride)
+	for top, bottom := 0, h-1; top < bottom; top, bottom = top+1, bottom-1 {
+		copy(row, img.Pix[top*stride:(top+1)*stride])
+		copy(img.Pix[top*stride:(top+1)*stride], img.Pix[bottom*stride:(bottom+1)*stride])
+		copy(img.Pix[bottom*stride:(bottom+1)*stride], row)
+	}
+	return img
+}
+
+func (p *PostProcessor) Destroy() {
+	gl.DeleteVertexArrays(1, &p.vao)
+	gl.DeleteBuffers(1, &p.vbo)
+	gl.DeleteFramebuffers(1, &p.fbo)
+	if p.Samples > 0 {
+		gl.DeleteFramebuffers(1, &p.msfbo)
+		gl.DeleteRenderbuffers(1, &p.rbo)
+	}
+	gl.DeleteTextures(1, &p.Texture.ID)
+}
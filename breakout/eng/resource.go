@@ -1,72 +1,144 @@
 package eng
 
 import (
-	"io/ioutil"
-	"os"
+	"errors"
+	"io/fs"
+	"log"
+	"time"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 )
 
+// LoadError is a resource file that couldn't be read or decoded.
+type LoadError struct {
+	Path string
+	Err  error
+}
+
+func (e *LoadError) Error() string {
+	return "failed to load " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// NotFoundError is a lookup of a resource that was never loaded.
+type NotFoundError struct {
+	// Kind is "shader" or "texture".
+	Kind string
+	Name string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Kind + " '" + e.Name + "' not found"
+}
+
 // Singleton
 type ResourceManager struct {
-	shaders map[string]*Shader
+	// FS is where files are loaded from.
+	FS fs.FS
+
+	shaders  map[string]*Shader
 	textures map[string]*Texture2D
+
+	// the files behind each resource, by name, for Reload
+	shaderFiles  map[string][2]string
+	textureFiles map[string]string
+
+	watcher       *Watcher
+	watchInterval time.Duration
+	lastPoll      time.Time
 }
 
-func NewResourceManager() *ResourceManager {
-	return  &ResourceManager{
-		shaders: map[string]*Shader{},
-		textures: map[string]*Texture2D{},
+// NewResourceManager creates a manager loading files from fsys, e.g. an
+// embed.FS, os.DirFS or the result of OpenFS or OverlayFS.
+func NewResourceManager(fsys fs.FS) *ResourceManager {
+	return &ResourceManager{
+		FS:           fsys,
+		shaders:      map[string]*Shader{},
+		textures:     map[string]*Texture2D{},
+		shaderFiles:  map[string][2]string{},
+		textureFiles: map[string]string{},
 	}
 }
 
-func (r *ResourceManager) LoadShader(vertexPath, fragmentPath, name string) *Shader {
-	var vertexCode, fragmentCode string
+// LoadShader compiles and links the shader files and stores the program as
+// name. Compile and link failures are a *ShaderError with Path set.
+func (r *ResourceManager) LoadShader(vertexPath, fragmentPath, name string) (*Shader, error) {
+	shader, err := r.compileShader(vertexPath, fragmentPath)
+	if err != nil {
+		return nil, err
+	}
+	r.shaders[name] = shader
+	r.shaderFiles[name] = [2]string{vertexPath, fragmentPath}
+	r.watch(vertexPath, fragmentPath)
+	return shader, nil
+}
 
-	{
-		bytes, err := ioutil.ReadFile(vertexPath)
-		if err != nil {
-			panic(err)
-		}
-		vertexCode = string(bytes)
+func (r *ResourceManager) compileShader(vertexPath, fragmentPath string) (*Shader, error) {
+	vertexCode, err := fs.ReadFile(r.FS, vertexPath)
+	if err != nil {
+		return nil, &LoadError{Path: vertexPath, Err: err}
+	}
+	fragmentCode, err := fs.ReadFile(r.FS, fragmentPath)
+	if err != nil {
+		return nil, &LoadError{Path: fragmentPath, Err: err}
+	}
 
-		bytes, err = ioutil.ReadFile(fragmentPath)
-		if err != nil {
-			panic(err)
+	shader, err := NewShader(string(vertexCode), string(fragmentCode))
+	var shaderErr *ShaderError
+	if errors.As(err, &shaderErr) {
+		switch shaderErr.Stage {
+		case "vertex":
+			shaderErr.Path = vertexPath
+		case "fragment":
+			shaderErr.Path = fragmentPath
+		default:
+			shaderErr.Path = vertexPath + " and " + fragmentPath
 		}
-		fragmentCode = string(bytes)
 	}
-
-	shader := NewShader(vertexCode, fragmentCode)
-	r.shaders[name] = shader
-	return shader
+	return shader, err
 }
 
-func (r *ResourceManager) Shader(name string) *Shader {
+func (r *ResourceManager) Shader(name string) (*Shader, error) {
 	shader, ok := r.shaders[name]
 	if !ok {
-		panic("Shader not found")
+		return nil, &NotFoundError{Kind: "shader", Name: name}
 	}
-	return shader
+	return shader, nil
 }
 
-func (r *ResourceManager) LoadTexture(file string, name string) *Texture2D {
+// LoadTexture decodes an image file into a texture stored as name.
+func (r *ResourceManager) LoadTexture(file string, name string) (*Texture2D, error) {
 	texture := NewTexture()
-	f, err := os.Open(file)
-	if err != nil {
-		panic(err)
+	if err := r.generateTexture(texture, file); err != nil {
+		gl.DeleteTextures(1, &texture.ID)
+		return nil, err
 	}
-	texture.Generate(f)
 	r.textures[name] = texture
-	return texture
+	r.textureFiles[name] = file
+	r.watch(file)
+	return texture, nil
 }
 
-func (r *ResourceManager) Texture(name string) *Texture2D {
+func (r *ResourceManager) generateTexture(texture *Texture2D, file string) error {
+	f, err := r.FS.Open(file)
+	if err != nil {
+		return &LoadError{Path: file, Err: err}
+	}
+	if err := texture.Generate(f); err != nil {
+		return &LoadError{Path: file, Err: err}
+	}
+	return nil
+}
+
+func (r *ResourceManager) Texture(name string) (*Texture2D, error) {
 	t, ok := r.textures[name]
 	if !ok {
-		panic("Texture '" + name + "' not found")
+		return nil, &NotFoundError{Kind: "texture", Name: name}
 	}
-	return t
+	return t, nil
 }
 
 func (r *ResourceManager) Clear() {
@@ -77,3 +149,71 @@ func (r *ResourceManager) Clear() {
 		gl.DeleteTextures(1, &texture.ID)
 	}
 }
+
+// Watch makes Reload look for changes to the files behind every shader and
+// texture, loaded already or later, at most once per interval.
+func (r *ResourceManager) Watch(interval time.Duration) {
+	r.watcher = NewWatcher(r.FS)
+	r.watchInterval = interval
+	for _, files := range r.shaderFiles {
+		r.watch(files[0], files[1])
+	}
+	for _, file := range r.textureFiles {
+		r.watch(file)
+	}
+}
+
+func (r *ResourceManager) watch(paths ...string) {
+	if r.watcher == nil {
+		return
+	}
+	for _, path := range paths {
+		r.watcher.Add(path)
+	}
+}
+
+// Reload recompiles shaders and re-uploads textures whose files changed,
+// keeping the same *Shader and *Texture2D so everything holding them sees the
+// change. A shader keeps its uniform values. Anything that fails to load is
+// logged and the old version kept. Reload must be called on the GL thread and
+// does nothing unless Watch was called.
+func (r *ResourceManager) Reload() {
+	if r.watcher == nil || time.Since(r.lastPoll) < r.watchInterval {
+		return
+	}
+	r.lastPoll = time.Now()
+
+	changed := map[string]bool{}
+	for _, path := range r.watcher.Changed() {
+		changed[path] = true
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	for name, files := range r.shaderFiles {
+		if !changed[files[0]] && !changed[files[1]] {
+			continue
+		}
+		shader, err := r.compileShader(files[0], files[1])
+		if err != nil {
+			log.Printf("keeping shader %s: %v", name, err)
+			continue
+		}
+		old := r.shaders[name]
+		copyUniforms(old.ID, shader.ID)
+		gl.DeleteProgram(old.ID)
+		old.ID = shader.ID
+		log.Printf("reloaded shader %s", name)
+	}
+	for name, file := range r.textureFiles {
+		if !changed[file] {
+			continue
+		}
+		if err := r.generateTexture(r.textures[name], file); err != nil {
+			log.Printf("keeping texture %s: %v", name, err)
+			continue
+		}
+		log.Printf("reloaded texture %s", name)
+	}
+}
@@ -9,13 +9,23 @@ import (
 )
 
 type Scene interface {
-	New(width, height int, window *glfw.Window)
+	// New loads the scene, failing if any of its resources won't load.
+	New(width, height int, window *glfw.Window) error
 	Render(float32)
 	Update(float32)
 	Close()
 }
 
-func Run(scene Scene, width, height int) {
+// Resizer is a Scene that wants the size of the window's framebuffer, in
+// pixels, when it starts and whenever it changes.
+type Resizer interface {
+	Resize(width, height int)
+}
+
+// Run opens a window as options say and runs scene in it until the window
+// is closed, updating it as loop says. width and height are the size the
+// scene is drawn at. A nil loop runs in real time at DefaultStep.
+func Run(scene Scene, width, height int, options WindowOptions, loop *Loop) {
 	runtime.LockOSThread()
 
 	// glfw: initialize and configure
@@ -23,69 +33,54 @@ func Run(scene Scene, width, height int) {
 		panic(err)
 	}
 	defer glfw.Terminate()
-	glfw.WindowHint(glfw.ContextVersionMajor, 3)
-	glfw.WindowHint(glfw.ContextVersionMinor, 3)
-	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
-
-	if runtime.GOOS == "darwin" {
-		glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
-	}
 
-	// glfw window creation
-	monitor := glfw.GetPrimaryMonitor()
-	videoMode := monitor.GetVideoMode()
-	window, err := glfw.CreateWindow(videoMode.Width, videoMode.Height, "Breakout", monitor, nil)
+	window, err := createWindow(options, width, height)
 	if err != nil {
 		panic(err)
 	}
 	defer window.Destroy()
-	window.MakeContextCurrent()
-
-	if err := gl.Init(); err != nil {
-		panic(err)
-	}
-	glfw.SwapInterval(1)
 
 	gl.Enable(gl.CULL_FACE)
 	gl.Enable(gl.BLEND)
 	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 
-	const dt = 1./60.
-	currentTime := glfw.GetTime()
-	accumulator := 0.0
+	if loop == nil {
+		loop = NewLoop(GLFWClock)
+	}
 
 	frames := 0
 	var lastFps float64
 
-	scene.New(width, height, window)
+	if err := scene.New(width, height, window); err != nil {
+		panic(err)
+	}
+	resize := func(width, height int) {
+		gl.Viewport(0, 0, int32(width), int32(height))
+		if r, ok := scene.(Resizer); ok {
+			r.Resize(width, height)
+		}
+	}
+	resize(window.GetFramebufferSize())
+	window.SetFramebufferSizeCallback(func(window *glfw.Window, width, height int) {
+		resize(width, height)
+	})
 
 	for !window.ShouldClose() {
 		frames++
 		glfw.PollEvents()
 
 		newTime := glfw.GetTime()
-		if newTime - lastFps > 1 {
-			window.SetTitle(fmt.Sprintf("Breakout | %d FPS", frames))
+		if newTime-lastFps > 1 {
+			window.SetTitle(fmt.Sprintf("%s | %d FPS", options.Title, frames))
 			frames = 0
 			lastFps = newTime
 		}
-		frameTime := newTime - currentTime
-		if frameTime > .25 {
-			frameTime = .25
-		}
-		currentTime = newTime
-		accumulator += frameTime
-
-		for accumulator >= dt{
-			scene.Update(dt)
-			accumulator -= dt
-		}
+		alpha := loop.Advance(scene.Update)
 
 		gl.ClearColor(0, 0, 0, 0.5)
 		gl.Clear(gl.COLOR_BUFFER_BIT)
 
-		alpha := accumulator / dt
-		scene.Render(float32(alpha))
+		scene.Render(alpha)
 		window.SwapBuffers()
 	}
 
@@ -1,7 +1,9 @@
 package eng
 
 import (
-	"log"
+	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
@@ -12,16 +14,74 @@ type Shader struct {
 	ID uint32
 }
 
-func NewShader(vertexCode, fragmentCode string) *Shader {
-	vertexShader := CompileShader(gl.VERTEX_SHADER, vertexCode)
-	fragmentShader := CompileShader(gl.FRAGMENT_SHADER, fragmentCode)
-	ID := LinkProgram(vertexShader, fragmentShader)
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
+// ShaderError is a shader stage that failed to compile, or a program that
+// failed to link.
+type ShaderError struct {
+	// Path is the file the source was read from, if there was one.
+	Path string
+	// Stage is "vertex", "fragment" or "link".
+	Stage string
+	// Log is the driver's info log and Lines the source lines it points at.
+	Log   string
+	Lines []int
+}
+
+func (e *ShaderError) Error() string {
+	msg := "failed to compile " + e.Stage + " shader"
+	if e.Stage == "link" {
+		msg = "failed to link shader program"
+	}
+	if e.Path != "" {
+		msg += " " + e.Path
+	}
+	if len(e.Lines) == 1 {
+		msg += fmt.Sprintf(" at line %d", e.Lines[0])
+	} else if len(e.Lines) > 1 {
+		lines := make([]string, len(e.Lines))
+		for i, line := range e.Lines {
+			lines[i] = strconv.Itoa(line)
+		}
+		msg += " at lines " + strings.Join(lines, ", ")
+	}
+	return msg + ": " + strings.TrimSpace(e.Log)
+}
+
+// infoLogLine matches the line number drivers put at the start of each
+// message, e.g. "0:12(5): error" (Mesa), "0(12) : error" (Nvidia) or
+// "ERROR: 0:12:" (AMD, Apple).
+var infoLogLine = regexp.MustCompile(`(?m)^\s*(?:ERROR:|WARNING:)?\s*\d+[:(](\d+)`)
+
+// parseInfoLog returns the source line numbers in a GLSL info log, in the
+// order they appear.
+func parseInfoLog(log string) []int {
+	var lines []int
+	for _, match := range infoLogLine.FindAllStringSubmatch(log, -1) {
+		if n, err := strconv.Atoi(match[1]); err == nil {
+			lines = append(lines, n)
+		}
+	}
+	return lines
+}
+
+func NewShader(vertexCode, fragmentCode string) (*Shader, error) {
+	vertexShader, err := CompileShader(gl.VERTEX_SHADER, vertexCode)
+	if err != nil {
+		return nil, err
+	}
+	defer gl.DeleteShader(vertexShader)
+	fragmentShader, err := CompileShader(gl.FRAGMENT_SHADER, fragmentCode)
+	if err != nil {
+		return nil, err
+	}
+	defer gl.DeleteShader(fragmentShader)
+	ID, err := LinkProgram(vertexShader, fragmentShader)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Shader{
 		ID: ID,
-	}
+	}, nil
 }
 
 func (s *Shader) Use() *Shader {
@@ -87,7 +147,8 @@ func CheckGLErrors() {
 	}
 }
 
-func CheckError(obj uint32, status uint32, getiv func(uint32, uint32, *int32), getInfoLog func(uint32, int32, *int32, *uint8)) bool {
+// CheckError returns the info log of obj if its status is false.
+func CheckError(obj uint32, status uint32, getiv func(uint32, uint32, *int32), getInfoLog func(uint32, int32, *int32, *uint8)) (string, bool) {
 	var success int32
 	getiv(obj, status, &success)
 
@@ -98,14 +159,15 @@ func CheckError(obj uint32, status uint32, getiv func(uint32, uint32, *int32), g
 		info := strings.Repeat("\x00", int(length+1))
 		getInfoLog(obj, length, nil, gl.Str(info))
 
-		log.Println("GL Error:", info)
-		return true
+		return strings.TrimRight(info, "\x00"), true
 	}
 
-	return false
+	return "", false
 }
 
-func CompileShader(typ uint32, source string) uint32 {
+// CompileShader compiles source as a shader of type typ, which is
+// gl.VERTEX_SHADER or gl.FRAGMENT_SHADER. Failures are a *ShaderError.
+func CompileShader(typ uint32, source string) (uint32, error) {
 	shader := gl.CreateShader(typ)
 
 	sources, free := gl.Strs(source + "\x00")
@@ -113,14 +175,20 @@ func CompileShader(typ uint32, source string) uint32 {
 	gl.ShaderSource(shader, 1, sources, nil)
 	gl.CompileShader(shader)
 
-	if CheckError(shader, gl.COMPILE_STATUS, gl.GetShaderiv, gl.GetShaderInfoLog) {
-		panic("Error compiling shader")
+	if log, failed := CheckError(shader, gl.COMPILE_STATUS, gl.GetShaderiv, gl.GetShaderInfoLog); failed {
+		gl.DeleteShader(shader)
+		stage := "vertex"
+		if typ == gl.FRAGMENT_SHADER {
+			stage = "fragment"
+		}
+		return 0, &ShaderError{Stage: stage, Log: log, Lines: parseInfoLog(log)}
 	}
 
-	return shader
+	return shader, nil
 }
 
-func LinkProgram(vshader, fshader uint32) uint32 {
+// LinkProgram links two compiled shaders. Failures are a *ShaderError.
+func LinkProgram(vshader, fshader uint32) (uint32, error) {
 	p := gl.CreateProgram()
 
 	gl.AttachShader(p, vshader)
@@ -128,11 +196,12 @@ func LinkProgram(vshader, fshader uint32) uint32 {
 
 	gl.LinkProgram(p)
 
-	if CheckError(p, gl.LINK_STATUS, gl.GetProgramiv, gl.GetProgramInfoLog) {
-		panic("Error linking shader program")
+	if log, failed := CheckError(p, gl.LINK_STATUS, gl.GetProgramiv, gl.GetProgramInfoLog); failed {
+		gl.DeleteProgram(p)
+		return 0, &ShaderError{Stage: "link", Log: log, Lines: parseInfoLog(log)}
 	}
 
-	return p
+	return p, nil
 }
 
 func SetAttribute(program uint32, name string, size int32, gltype uint32, stride int32, offset int) {
@@ -141,3 +210,59 @@ func SetAttribute(program uint32, name string, size int32, gltype uint32, stride
 	gl.VertexAttribPointer(index, size, gltype, false, stride, gl.PtrOffset(offset))
 	CheckGLErrors()
 }
+
+// copyUniforms sets every active uniform of program to that uniform's value
+// in old, where program has it too.
+func copyUniforms(old, program uint32) {
+	var count, maxLength int32
+	gl.GetProgramiv(old, gl.ACTIVE_UNIFORMS, &count)
+	gl.GetProgramiv(old, gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLength)
+	if count == 0 {
+		return
+	}
+	buf := make([]uint8, maxLength+1)
+
+	gl.UseProgram(program)
+	for i := int32(0); i < count; i++ {
+		var length, size int32
+		var typ uint32
+		gl.GetActiveUniform(old, uint32(i), int32(len(buf)), &length, &size, &typ, &buf[0])
+		name := strings.TrimSuffix(string(buf[:length]), "[0]")
+
+		for element := int32(0); element < size; element++ {
+			elementName := name
+			if size > 1 {
+				elementName = fmt.Sprintf("%s[%d]", name, element)
+			}
+			from := gl.GetUniformLocation(old, gl.Str(elementName+"\x00"))
+			to := gl.GetUniformLocation(program, gl.Str(elementName+"\x00"))
+			if from < 0 || to < 0 {
+				continue
+			}
+
+			var f [16]float32
+			var n [4]int32
+			switch typ {
+			case gl.FLOAT:
+				gl.GetUniformfv(old, from, &f[0])
+				gl.Uniform1fv(to, 1, &f[0])
+			case gl.FLOAT_VEC2:
+				gl.GetUniformfv(old, from, &f[0])
+				gl.Uniform2fv(to, 1, &f[0])
+			case gl.FLOAT_VEC3:
+				gl.GetUniformfv(old, from, &f[0])
+				gl.Uniform3fv(to, 1, &f[0])
+			case gl.FLOAT_VEC4:
+				gl.GetUniformfv(old, from, &f[0])
+				gl.Uniform4fv(to, 1, &f[0])
+			case gl.FLOAT_MAT4:
+				gl.GetUniformfv(old, from, &f[0])
+				gl.UniformMatrix4fv(to, 1, false, &f[0])
+			case gl.INT, gl.BOOL, gl.SAMPLER_2D:
+				gl.GetUniformiv(old, from, &n[0])
+				gl.Uniform1iv(to, 1, &n[0])
+			}
+		}
+	}
+	gl.UseProgram(0)
+}
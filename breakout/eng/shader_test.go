@@ -0,0 +1,73 @@
+package eng
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestShaderInfoLog(t *testing.T) {
+	tests := []struct {
+		name  string
+		stage string
+		log   string
+		lines []int
+		err   string
+	}{
+		{
+			"nvidia",
+			"fragment",
+			"0(12) : error C1008: undefined variable \"colour\"\n",
+			[]int{12},
+			"failed to compile fragment shader shaders/sprite.fs at line 12: 0(12) : error C1008: undefined variable \"colour\"",
+		},
+		{
+			"nvidia several",
+			"vertex",
+			"0(3) : warning C7022: unrecognized profile specifier \"cor\"\n" +
+				"0(9) : error C0000: syntax error, unexpected '}', expecting ',' or ';' at token \"}\"\n" +
+				"0(14) : error C1503: undefined variable \"projection\"\n",
+			[]int{3, 9, 14},
+			"failed to compile vertex shader shaders/sprite.fs at lines 3, 9, 14: 0(3) : warning C7022",
+		},
+		{
+			"mesa",
+			"fragment",
+			"0:7(19): error: `texCoords' undeclared\n",
+			[]int{7},
+			"failed to compile fragment shader shaders/sprite.fs at line 7: 0:7(19): error: `texCoords' undeclared",
+		},
+		{
+			"mesa several",
+			"fragment",
+			"0:5(1): warning: extension `GL_foo' unsupported\n" +
+				"0:21(2): error: syntax error, unexpected NEW_IDENTIFIER, expecting ',' or ';'\n",
+			[]int{5, 21},
+			"failed to compile fragment shader shaders/sprite.fs at lines 5, 21: 0:5(1): warning",
+		},
+		{
+			"amd",
+			"vertex",
+			"ERROR: 0:4: 'vec5' : syntax error\nERROR: 1 compilation errors.  No code generated.\n",
+			[]int{4},
+			"failed to compile vertex shader shaders/sprite.fs at line 4: ERROR: 0:4:",
+		},
+		{
+			"no lines",
+			"link",
+			"error: vertex shader output `TexCoords' not read by fragment shader\n",
+			nil,
+			"failed to link shader program shaders/sprite.fs: error: vertex shader output",
+		},
+	}
+	for _, test := range tests {
+		lines := parseInfoLog(test.log)
+		if fmt.Sprint(lines) != fmt.Sprint(test.lines) {
+			t.Errorf("%s: lines %v, want %v", test.name, lines, test.lines)
+		}
+		err := &ShaderError{Path: "shaders/sprite.fs", Stage: test.stage, Log: test.log, Lines: lines}
+		if got := err.Error(); !strings.HasPrefix(got, test.err) {
+			t.Errorf("%s: error %q, want it to start %q", test.name, got, test.err)
+		}
+	}
+}
@@ -5,14 +5,38 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 )
 
+// maxBatchSprites is how many sprites a batch holds before it is flushed.
+const maxBatchSprites = 1024
+
+// floats per batched vertex: <vec2 position, vec2 texCoords, vec3 color>
+const batchVertexSize = 7
+
 type SpriteRenderer struct {
-	shader  *Shader
-	quadVAO uint32
+	shader           *Shader
+	quadVAO, quadVBO uint32
+
+	// batching state, see Begin
+	batching           bool
+	batchVAO, batchVBO uint32
+	batchEBO           uint32
+	batchTexture       *Texture2D
+	vertices           []float32
+
+	// DrawCalls counts every draw call issued, for profiling.
+	DrawCalls int
+
+	// issue the draw calls, one sprite at a time and a batch at a time,
+	// replaced in tests to count them without GL
+	drawQuad  func(texture *Texture2D, model mgl32.Mat4, color mgl32.Vec3)
+	drawBatch func(texture *Texture2D, vertices []float32)
 }
 
 func NewSpriteRenderer(shader *Shader) *SpriteRenderer {
 	renderer := &SpriteRenderer{shader: shader}
+	renderer.drawQuad = renderer.glDrawQuad
+	renderer.drawBatch = renderer.glDrawBatch
 	renderer.initRenderData()
+	renderer.initBatchData()
 	return renderer
 }
 
@@ -22,8 +46,52 @@ var (
 	DefaultColor      = mgl32.Vec3{1, 1, 1}
 )
 
+// SetProjection sets the projection sprites are drawn through.
+func (s *SpriteRenderer) SetProjection(projection mgl32.Mat4) {
+	s.shader.Use().SetMat4("projection", projection)
+}
+
+// DrawSprite draws texture as a sprite, or queues it when between Begin and End.
 func (s *SpriteRenderer) DrawSprite(texture *Texture2D, position, size mgl32.Vec2, rotate float64, color mgl32.Vec3) {
+	model := spriteModel(position, size, rotate)
+	if s.batching {
+		s.batch(texture, model, color)
+		return
+	}
+	s.drawQuad(texture, model, color)
+	s.DrawCalls++
+}
+
+func (s *SpriteRenderer) glDrawQuad(texture *Texture2D, model mgl32.Mat4, color mgl32.Vec3) {
 	s.shader.Use()
+	s.shader.SetMat4("model", model)
+	s.shader.SetVec3f("spriteColor", color)
+	gl.VertexAttrib3f(1, 1, 1, 1)
+
+	gl.ActiveTexture(gl.TEXTURE0)
+	texture.Bind()
+
+	gl.BindVertexArray(s.quadVAO)
+	gl.DrawArrays(gl.TRIANGLES, 0, 6)
+	gl.BindVertexArray(0)
+}
+
+// Begin starts a batch. Sprites drawn until End are gathered into one
+// vertex buffer and drawn together, with a draw call only when the texture
+// changes or the batch is full.
+func (s *SpriteRenderer) Begin() {
+	s.batching = true
+	s.batchTexture = nil
+	s.vertices = s.vertices[:0]
+}
+
+// End draws whatever is left in the batch and stops batching.
+func (s *SpriteRenderer) End() {
+	s.flush()
+	s.batching = false
+}
+
+func spriteModel(position, size mgl32.Vec2, rotate float64) mgl32.Mat4 {
 	var model mgl32.Mat4
 	model = mgl32.Translate3D(position.X(), position.Y(), 0)
 
@@ -32,20 +100,52 @@ func (s *SpriteRenderer) DrawSprite(texture *Texture2D, position, size mgl32.Vec
 	model = model.Mul4(mgl32.Translate3D(-0.5*size.X(), -0.5*size.Y(), 0))
 
 	model = model.Mul4(mgl32.Scale3D(size.X(), size.Y(), 1))
+	return model
+}
 
-	s.shader.SetMat4("model", model)
-	s.shader.SetVec3f("spriteColor", color)
+// batch transforms the unit quad on the CPU, as the vertex shader would with
+// model, and appends it.
+func (s *SpriteRenderer) batch(texture *Texture2D, model mgl32.Mat4, color mgl32.Vec3) {
+	if texture != s.batchTexture || len(s.vertices) >= maxBatchSprites*4*batchVertexSize {
+		s.flush()
+		s.batchTexture = texture
+	}
+	corners := [4]mgl32.Vec2{{0, 0}, {1, 0}, {0, 1}, {1, 1}}
+	for _, corner := range corners {
+		pos := model.Mul4x1(mgl32.Vec4{corner.X(), corner.Y(), 0, 1})
+		s.vertices = append(s.vertices,
+			pos.X(), pos.Y(), corner.X(), corner.Y(),
+			color.X(), color.Y(), color.Z())
+	}
+}
+
+func (s *SpriteRenderer) flush() {
+	if len(s.vertices) == 0 {
+		return
+	}
+	s.drawBatch(s.batchTexture, s.vertices)
+	s.DrawCalls++
+	s.vertices = s.vertices[:0]
+}
+
+func (s *SpriteRenderer) glDrawBatch(texture *Texture2D, vertices []float32) {
+	s.shader.Use()
+	s.shader.SetMat4("model", mgl32.Ident4())
+	s.shader.SetVec3f("spriteColor", DefaultColor)
 
 	gl.ActiveTexture(gl.TEXTURE0)
 	texture.Bind()
 
-	gl.BindVertexArray(s.quadVAO)
-	gl.DrawArrays(gl.TRIANGLES, 0, 6)
+	gl.BindVertexArray(s.batchVAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, s.batchVBO)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(vertices)*4, gl.Ptr(vertices))
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	quads := len(vertices) / (4 * batchVertexSize)
+	gl.DrawElements(gl.TRIANGLES, int32(quads*6), gl.UNSIGNED_INT, gl.PtrOffset(0))
 	gl.BindVertexArray(0)
 }
 
 func (s *SpriteRenderer) initRenderData() {
-	var VBO uint32
 	vertices := []float32{
 		0, 1, 0, 1,
 		1, 0, 1, 0,
@@ -57,9 +157,9 @@ func (s *SpriteRenderer) initRenderData() {
 	}
 
 	gl.GenVertexArrays(1, &s.quadVAO)
-	gl.GenBuffers(1, &VBO)
+	gl.GenBuffers(1, &s.quadVBO)
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, VBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, s.quadVBO)
 	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
 
 	gl.BindVertexArray(s.quadVAO)
@@ -69,6 +169,38 @@ func (s *SpriteRenderer) initRenderData() {
 	gl.BindVertexArray(0)
 }
 
+func (s *SpriteRenderer) initBatchData() {
+	// same winding as the quad above: corners are 0 top left, 1 top right,
+	// 2 bottom left and 3 bottom right
+	indices := make([]uint32, 0, maxBatchSprites*6)
+	for i := uint32(0); i < maxBatchSprites; i++ {
+		v := i * 4
+		indices = append(indices, v+2, v+1, v, v+2, v+3, v+1)
+	}
+	s.vertices = make([]float32, 0, maxBatchSprites*4*batchVertexSize)
+
+	gl.GenVertexArrays(1, &s.batchVAO)
+	gl.GenBuffers(1, &s.batchVBO)
+	gl.GenBuffers(1, &s.batchEBO)
+
+	gl.BindVertexArray(s.batchVAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, s.batchVBO)
+	gl.BufferData(gl.ARRAY_BUFFER, cap(s.vertices)*4, nil, gl.DYNAMIC_DRAW)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, s.batchEBO)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
+
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 4, gl.FLOAT, false, batchVertexSize*4, gl.PtrOffset(0))
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, batchVertexSize*4, gl.PtrOffset(4*4))
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
+}
+
 func (s *SpriteRenderer) Destroy() {
 	gl.DeleteVertexArrays(1, &s.quadVAO)
+	gl.DeleteBuffers(1, &s.quadVBO)
+	gl.DeleteVertexArrays(1, &s.batchVAO)
+	gl.DeleteBuffers(1, &s.batchVBO)
+	gl.DeleteBuffers(1, &s.batchEBO)
 }
@@ -0,0 +1,148 @@
+package eng
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// countingRenderer is a sprite renderer that counts sprites instead of
+// drawing them, so it runs without GL.
+func countingRenderer(sprites *int) *SpriteRenderer {
+	s := &SpriteRenderer{}
+	s.drawQuad = func(*Texture2D, mgl32.Mat4, mgl32.Vec3) {
+		*sprites++
+	}
+	s.drawBatch = func(_ *Texture2D, vertices []float32) {
+		*sprites += len(vertices) / (4 * batchVertexSize)
+	}
+	return s
+}
+
+// drawScene draws count sprites cycling through textures in runs of run.
+func drawScene(s *SpriteRenderer, textures []*Texture2D, count, run int) {
+	for i := 0; i < count; i++ {
+		texture := textures[i/run%len(textures)]
+		s.DrawSprite(texture, mgl32.Vec2{float32(i), 0}, DefaultSpriteSize, 0, DefaultColor)
+	}
+}
+
+func TestSpriteBatchDrawCalls(t *testing.T) {
+	textures := []*Texture2D{{}, {}, {}}
+	tests := []struct {
+		name       string
+		count, run int
+		batched    int
+	}{
+		{"grouped by texture", 300, 100, 3},
+		{"alternating textures", 300, 1, 300},
+		{"runs of ten", 300, 10, 30},
+		{"one texture", 100, 100, 1},
+		{"over a full batch", maxBatchSprites + 1, maxBatchSprites + 1, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var drawn int
+			s := countingRenderer(&drawn)
+			drawScene(s, textures, test.count, test.run)
+			if s.DrawCalls != test.count || drawn != test.count {
+				t.Fatalf("one at a time: %d draw calls for %d sprites, want %d for %d", s.DrawCalls, drawn, test.count, test.count)
+			}
+
+			drawn = 0
+			s.DrawCalls = 0
+			s.Begin()
+			drawScene(s, textures, test.count, test.run)
+			s.End()
+			if s.DrawCalls != test.batched || drawn != test.count {
+				t.Errorf("batched: %d draw calls for %d sprites, want %d for %d", s.DrawCalls, drawn, test.batched, test.count)
+			}
+		})
+	}
+}
+
+func TestSpriteBatchVertices(t *testing.T) {
+	s := &SpriteRenderer{}
+	var got []float32
+	s.drawBatch = func(_ *Texture2D, vertices []float32) {
+		got = append(got, vertices...)
+	}
+	s.Begin()
+	s.DrawSprite(&Texture2D{}, mgl32.Vec2{10, 20}, mgl32.Vec2{30, 40}, 0, mgl32.Vec3{.1, .2, .3})
+	s.End()
+
+	// the corners in order top left, top right, bottom left, bottom right
+	want := []float32{
+		10, 20, 0, 0, .1, .2, .3,
+		40, 20, 1, 0, .1, .2, .3,
+		10, 60, 0, 1, .1, .2, .3,
+		40, 60, 1, 1, .1, .2, .3,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d floats, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !mgl32.FloatEqualThreshold(got[i], want[i], 1e-4) {
+			t.Fatalf("vertices = %v, want %v", got, want)
+		}
+	}
+}
+
+// benchLevel is the shipped levels/4.txt: 0 is empty, 1 solid and the rest
+// blocks tinted by their hit points.
+var benchLevel = []string{
+	"1 5 5 5 5 5 5 5 5 5 5 5 5 5 1",
+	"1 4 4 4 4 4 4 4 4 4 4 4 4 4 1",
+	"1 3 1 3 1 3 1 3 1 3 1 3 1 3 1",
+	"1 3 3 3 3 3 3 3 3 3 3 3 3 3 1",
+	"1 2 2 2 2 1 1 0 1 1 2 2 2 2 1",
+	"1 2 2 2 2 2 2 2 2 2 2 2 2 2 1",
+	"1 5 4 3 2 5 4 3 2 5 4 3 2 5 1",
+	"1 0 0 0 0 0 0 0 0 0 0 0 0 0 1",
+}
+
+// drawLevel draws a frame of benchLevel as the game does: the background,
+// the bricks a texture at a time, then the paddle.
+func drawLevel(s *SpriteRenderer, background, solid, block, paddle *Texture2D) {
+	const width, height = 800, 600
+	s.DrawSprite(background, mgl32.Vec2{}, mgl32.Vec2{width, height}, 0, DefaultColor)
+	size := mgl32.Vec2{width / 15, height / 2 / float32(len(benchLevel))}
+	for _, texture := range []*Texture2D{solid, block} {
+		for y, row := range benchLevel {
+			for x := 0; x < len(row); x += 2 {
+				tile := row[x]
+				if tile == '0' || (tile == '1') != (texture == solid) {
+					continue
+				}
+				position := mgl32.Vec2{float32(x/2) * size.X(), float32(y) * size.Y()}
+				s.DrawSprite(texture, position, size, 0, DefaultColor)
+			}
+		}
+	}
+	s.DrawSprite(paddle, mgl32.Vec2{350, height - 20}, mgl32.Vec2{100, 20}, 0, DefaultColor)
+}
+
+func BenchmarkSpriteBatch(b *testing.B) {
+	background, solid, block, paddle := &Texture2D{}, &Texture2D{}, &Texture2D{}, &Texture2D{}
+	for _, batched := range []bool{false, true} {
+		name := "unbatched"
+		if batched {
+			name = "batched"
+		}
+		b.Run(name, func(b *testing.B) {
+			var drawn int
+			s := countingRenderer(&drawn)
+			for i := 0; i < b.N; i++ {
+				if batched {
+					s.Begin()
+				}
+				drawLevel(s, background, solid, block, paddle)
+				if batched {
+					s.End()
+				}
+			}
+			b.ReportMetric(float64(s.DrawCalls)/float64(b.N), "draws/frame")
+			b.ReportMetric(float64(drawn)/float64(b.N), "sprites/frame")
+		})
+	}
+}
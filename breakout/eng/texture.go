@@ -34,11 +34,12 @@ func NewTexture() *Texture2D {
 	}
 }
 
-func (t *Texture2D) Generate(reader io.ReadCloser) {
+// Generate decodes an image from reader and uploads it to the texture.
+func (t *Texture2D) Generate(reader io.ReadCloser) error {
 	defer reader.Close()
 	img, _, err := image.Decode(reader)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	rgba := image.NewRGBA(img.Bounds())
@@ -59,7 +60,7 @@ func (t *Texture2D) Generate(reader io.ReadCloser) {
 	// unbind
 	gl.BindTexture(gl.TEXTURE_2D, 0)
 
-	return
+	return nil
 }
 
 func (t *Texture2D) Bind() {
@@ -0,0 +1,68 @@
+package eng
+
+import (
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// Watcher polls files in a fs.FS for changes. It has no goroutines of its
+// own, so whoever calls Changed decides when and on which thread to look.
+type Watcher struct {
+	fs    fs.FS
+	files map[string]fileStamp
+}
+
+// fileStamp is what a file looked like when last polled. A missing file has
+// the zero stamp, so it counts as changed once it appears.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func NewWatcher(fsys fs.FS) *Watcher {
+	return &Watcher{
+		fs:    fsys,
+		files: map[string]fileStamp{},
+	}
+}
+
+// Add starts watching path, as it is now. A path that doesn't exist yet
+// changes when it appears.
+func (w *Watcher) Add(path string) {
+	w.files[path] = w.stamp(path)
+}
+
+// Watching reports whether path is watched.
+func (w *Watcher) Watching(path string) bool {
+	_, ok := w.files[path]
+	return ok
+}
+
+// Remove stops watching path.
+func (w *Watcher) Remove(path string) {
+	delete(w.files, path)
+}
+
+// Changed returns the watched paths that were modified since Add or the last
+// call to Changed, sorted.
+func (w *Watcher) Changed() []string {
+	var changed []string
+	for path, old := range w.files {
+		stamp := w.stamp(path)
+		if stamp != old {
+			w.files[path] = stamp
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func (w *Watcher) stamp(path string) fileStamp {
+	info, err := fs.Stat(w.fs, path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
@@ -0,0 +1,41 @@
+package eng
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestWatcherChanged(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"levels/1.txt": {Data: []byte("2"), ModTime: start},
+	}
+	w := NewWatcher(fsys)
+	w.Add("levels/1.txt")
+	// saved by the editor later on
+	w.Add("levels/1.txt.edited.json")
+	if !w.Watching("levels/1.txt.edited.json") || w.Watching("levels/2.txt") {
+		t.Fatal("watching the wrong files")
+	}
+	if changed := w.Changed(); len(changed) > 0 {
+		t.Fatalf("changed %v before anything did", changed)
+	}
+
+	fsys["levels/1.txt"] = &fstest.MapFile{Data: []byte("3"), ModTime: start.Add(time.Second)}
+	fsys["levels/1.txt.edited.json"] = &fstest.MapFile{Data: []byte("{}"), ModTime: start}
+	if got := fmt.Sprint(w.Changed()); got != "[levels/1.txt levels/1.txt.edited.json]" {
+		t.Errorf("changed %v, want the modified file and the new one", got)
+	}
+	if changed := w.Changed(); len(changed) > 0 {
+		t.Errorf("changed %v again without a change", changed)
+	}
+
+	delete(fsys, "levels/1.txt")
+	w.Remove("levels/1.txt.edited.json")
+	fsys["levels/1.txt.edited.json"].Data = []byte("{ }")
+	if got := fmt.Sprint(w.Changed()); got != "[levels/1.txt]" {
+		t.Errorf("changed %v, want the removed file and not the unwatched one", got)
+	}
+}
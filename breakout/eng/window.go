@@ -0,0 +1,185 @@
+package eng
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// WindowMode is how a window takes up its monitor.
+type WindowMode int
+
+const (
+	// Windowed is a normal window, centered on its monitor.
+	Windowed WindowMode = iota
+	// Fullscreen switches the monitor to the window's size.
+	Fullscreen
+	// Borderless covers the monitor with an undecorated window, leaving its
+	// video mode alone.
+	Borderless
+)
+
+var windowModes = [...]string{
+	Windowed:   "windowed",
+	Fullscreen: "fullscreen",
+	Borderless: "borderless",
+}
+
+func (m WindowMode) String() string {
+	if m < 0 || int(m) >= len(windowModes) {
+		return fmt.Sprintf("WindowMode(%d)", int(m))
+	}
+	return windowModes[m]
+}
+
+// Set reads a mode by name, as a flag.Value.
+func (m *WindowMode) Set(name string) error {
+	for mode, n := range windowModes {
+		if n == name {
+			*m = WindowMode(mode)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown window mode %q, want windowed, fullscreen or borderless", name)
+}
+
+func (m WindowMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *WindowMode) UnmarshalText(text []byte) error {
+	return m.Set(string(text))
+}
+
+// WindowOptions is how Run creates its window and GL context.
+type WindowOptions struct {
+	Mode  WindowMode `json:"mode"`
+	Title string     `json:"title"`
+	// Width and Height are the window's size, or the video mode to switch to
+	// when Fullscreen. Zero is the scene's size when windowed and the
+	// monitor's current mode otherwise.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// Monitor indexes the connected monitors, 0 being the primary one.
+	Monitor int  `json:"monitor"`
+	VSync   bool `json:"vsync"`
+	// Samples is how many samples the window's framebuffer has per pixel
+	// for multisample antialiasing, none if 0.
+	Samples   int  `json:"samples"`
+	Resizable bool `json:"resizable"`
+	// Debug asks for a debug context and logs what GL reports through it.
+	Debug bool `json:"debug"`
+}
+
+// DefaultWindowOptions are a resizable window with vsync on.
+var DefaultWindowOptions = WindowOptions{
+	Mode:      Windowed,
+	Title:     "learnopengl",
+	VSync:     true,
+	Resizable: true,
+}
+
+// glfwBool is a window hint's value for b.
+func glfwBool(b bool) int {
+	if b {
+		return glfw.True
+	}
+	return glfw.False
+}
+
+// monitor is the monitor the options ask for, falling back to the primary
+// one if it isn't connected.
+func (o WindowOptions) monitor() *glfw.Monitor {
+	monitors := glfw.GetMonitors()
+	if o.Monitor > 0 && o.Monitor < len(monitors) {
+		return monitors[o.Monitor]
+	}
+	if o.Monitor != 0 {
+		log.Printf("monitor %d isn't connected, there are %d; using the primary monitor", o.Monitor, len(monitors))
+	}
+	return glfw.GetPrimaryMonitor()
+}
+
+// createWindow creates the window and makes its context current. width and
+// height are the scene's size, for a window that doesn't give one.
+func createWindow(o WindowOptions, width, height int) (*glfw.Window, error) {
+	glfw.WindowHint(glfw.ContextVersionMajor, 3)
+	glfw.WindowHint(glfw.ContextVersionMinor, 3)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	if runtime.GOOS == "darwin" {
+		glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	}
+	glfw.WindowHint(glfw.Samples, o.Samples)
+	glfw.WindowHint(glfw.Resizable, glfwBool(o.Resizable))
+	glfw.WindowHint(glfw.OpenGLDebugContext, glfwBool(o.Debug))
+
+	monitor := o.monitor()
+	mode := monitor.GetVideoMode()
+	mx, my := monitor.GetPos()
+
+	var window *glfw.Window
+	var err error
+	switch o.Mode {
+	case Fullscreen:
+		w, h := mode.Width, mode.Height
+		if o.Width > 0 && o.Height > 0 {
+			w, h = o.Width, o.Height
+		}
+		glfw.WindowHint(glfw.RefreshRate, mode.RefreshRate)
+		window, err = glfw.CreateWindow(w, h, o.Title, monitor, nil)
+	case Borderless:
+		glfw.WindowHint(glfw.Decorated, glfw.False)
+		window, err = glfw.CreateWindow(mode.Width, mode.Height, o.Title, nil, nil)
+		if err == nil {
+			window.SetPos(mx, my)
+		}
+	default:
+		w, h := width, height
+		if o.Width > 0 && o.Height > 0 {
+			w, h = o.Width, o.Height
+		}
+		window, err = glfw.CreateWindow(w, h, o.Title, nil, nil)
+		if err == nil {
+			window.SetPos(mx+(mode.Width-w)/2, my+(mode.Height-h)/2)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	window.MakeContextCurrent()
+
+	if err := gl.Init(); err != nil {
+		window.Destroy()
+		return nil, err
+	}
+	if o.VSync {
+		glfw.SwapInterval(1)
+	} else {
+		glfw.SwapInterval(0)
+	}
+	if o.Samples > 0 {
+		gl.Enable(gl.MULTISAMPLE)
+	}
+	if o.Debug {
+		enableDebugOutput()
+	}
+	return window, nil
+}
+
+// enableDebugOutput logs the messages a debug context reports, as they
+// happen so the log lines up with the call that caused them.
+func enableDebugOutput() {
+	if !glfw.ExtensionSupported("GL_KHR_debug") {
+		log.Print("debug context asked for, but GL_KHR_debug isn't supported")
+		return
+	}
+	gl.Enable(gl.DEBUG_OUTPUT)
+	gl.Enable(gl.DEBUG_OUTPUT_SYNCHRONOUS)
+	gl.DebugMessageCallback(func(source, typ, id, severity uint32, length int32, message string, userParam unsafe.Pointer) {
+		log.Printf("gl: %s", message)
+	}, nil)
+}
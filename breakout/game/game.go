@@ -0,0 +1,737 @@
+// Package game renders a breakout.Simulation in a window and feeds it input.
+package game
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"path/filepath"
+	"time"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/jakecoffman/learnopengl/breakout"
+	"github.com/jakecoffman/learnopengl/breakout/eng"
+	"github.com/jakecoffman/learnopengl/breakout/input"
+)
+
+// Game renders a Simulation and feeds it input from the window.
+type Game struct {
+	// FS is where assets are loaded from, Assets if left nil.
+	FS fs.FS
+	// Watch reloads shaders, textures and levels when their files change,
+	// which only files from a directory in FS do; embedded ones never do.
+	Watch         bool
+	levelWatcher  *eng.Watcher
+	lastLevelPoll time.Time
+
+	// ProgressFile is where progress is saved between runs,
+	// DefaultProgressFile if empty.
+	ProgressFile string
+	saved        breakout.Progress
+
+	// EditDir is where the level editor saves levels, laid out like the
+	// assets so it can be used as an overlay. The editor is only offered
+	// when it is set.
+	EditDir string
+
+	// RecordFile, if set, is where the session's input is saved as a Replay
+	// when the game closes.
+	RecordFile string
+	// Replay, if set, is played back in place of the keyboard, mouse and
+	// joystick. Progress
+	// isn't saved while it plays.
+	Replay *breakout.Replay
+	// Bindings rebinds actions from DefaultBindings, e.g. from a config
+	// file.
+	Bindings input.Bindings
+	// Controls are how the paddle responds to the mouse and sticks.
+	Controls breakout.Controls
+
+	// WindowOptions are what the window is opened with, for the offscreen
+	// target to have as many samples and vsync to start as it is.
+	WindowOptions eng.WindowOptions
+
+	// Loop is the loop the game is run by, for the debug keys to step and
+	// slow down. They do nothing without it.
+	Loop *eng.Loop
+
+	*breakout.Simulation
+	window     *glfw.Window
+	vsync      int
+	cursorMode int
+
+	// used for slerp
+	LastPlayerPosition mgl32.Vec2
+	LastBallPosition   mgl32.Vec2
+
+	*eng.ResourceManager
+	// Camera letterboxes the play field into the window. The HUD is drawn
+	// on its screen, the rest in its world.
+	Camera         *eng.Camera2D
+	SpriteRenderer *eng.SpriteRenderer
+	TextRenderer   *eng.TextRenderer
+	Effects        *eng.PostProcessor
+	Background     *eng.Texture2D
+
+	// particles behind the ball, out of destroyed bricks and around falling
+	// power-ups
+	Trail, Explosions, Sparkles *eng.ParticleGenerator
+}
+
+var (
+	explosionConfig = eng.EmitterConfig{
+		Amount:        500,
+		LifeMin:       .4,
+		LifeMax:       .8,
+		Jitter:        10,
+		Cone:          2 * math.Pi,
+		SpeedMin:      50,
+		SpeedMax:      200,
+		Gravity:       mgl32.Vec2{0, 400},
+		SizeStart:     8,
+		SizeEnd:       2,
+		ColorStart:    mgl32.Vec4{1, .9, .7, 1},
+		ColorEnd:      mgl32.Vec4{1, .4, .1, 0},
+		BrightnessMin: .8,
+		BrightnessMax: 1.2,
+		Blend:         eng.BlendAdditive,
+	}
+	sparkleConfig = eng.EmitterConfig{
+		Amount:        200,
+		SpawnRate:     30,
+		LifeMin:       .3,
+		LifeMax:       .6,
+		Jitter:        20,
+		Cone:          2 * math.Pi,
+		SpeedMin:      10,
+		SpeedMax:      40,
+		SizeStart:     6,
+		SizeEnd:       0,
+		ColorStart:    mgl32.Vec4{1, 1, .6, 1},
+		ColorEnd:      mgl32.Vec4{1, 1, .6, 0},
+		BrightnessMin: 1,
+		BrightnessMax: 1,
+		Blend:         eng.BlendAlpha,
+	}
+)
+
+// watchInterval is how often files are checked for changes with Watch on.
+const watchInterval = 500 * time.Millisecond
+
+// shaderFiles are the shaders the game loads, by name, from shaders.
+var shaderFiles = []struct{ name, vertex, fragment string }{
+	{"sprite", "main.vs.glsl", "main.fs.glsl"},
+	{"particle", "particle.vs.glsl", "particle.fs.glsl"},
+	{"text", "text.vs.glsl", "text.fs.glsl"},
+	{"postprocessing", "post.vs.glsl", "post.fs.glsl"},
+}
+
+// textureFiles are the textures the game loads, by name, from textures.
+// Power-up textures are added from their sprite names.
+var textureFiles = map[string]string{
+	"background":              "background.jpg",
+	breakout.PaddleSprite:     "paddle.png",
+	"particle":                "particle.png",
+	breakout.BallSprite:       "awesomeface.png",
+	breakout.BlockSprite:      "block.png",
+	breakout.SolidBlockSprite: "block_solid.png",
+}
+
+func (g *Game) New(w, h int, window *glfw.Window) error {
+	g.window = window
+	if g.WindowOptions.VSync {
+		g.vsync = 1
+	}
+	g.Simulation = breakout.NewSimulation(w, h)
+	g.Bind(g.Bindings)
+	g.SetControls(g.Controls)
+	g.cursorMode = glfw.CursorNormal
+	if g.FS == nil {
+		g.FS = breakout.Assets
+	}
+	g.ResourceManager = eng.NewResourceManager(g.FS)
+	if g.Watch {
+		g.ResourceManager.Watch(watchInterval)
+	}
+
+	width, height := float32(g.Width), float32(g.Height)
+
+	shaders := map[string]*eng.Shader{}
+	for _, file := range shaderFiles {
+		shader, err := g.LoadShader("shaders/"+file.vertex, "shaders/"+file.fragment, file.name)
+		if err != nil {
+			return err
+		}
+		shaders[file.name] = shader
+	}
+	textures := map[string]*eng.Texture2D{}
+	for name, file := range textureFiles {
+		texture, err := g.LoadTexture("textures/"+file, name)
+		if err != nil {
+			return err
+		}
+		textures[name] = texture
+	}
+	for _, sprite := range breakout.PowerUpSprites() {
+		if _, err := g.LoadTexture("textures/"+sprite+".png", sprite); err != nil {
+			return err
+		}
+	}
+
+	shaders["sprite"].Use().SetInt("sprite", 0)
+	shaders["particle"].Use().SetInt("sprite", 0)
+
+	var err error
+	g.TextRenderer, err = eng.NewTextRenderer(shaders["text"], width, height, g.FS, "textures/Roboto-Light.ttf", 24)
+	if err != nil {
+		return err
+	}
+	g.TextRenderer.SetColor(1, 1, 1, 1)
+
+	g.Trail = eng.NewParticleGenerator(shaders["particle"], textures["particle"], eng.DefaultEmitterConfig)
+	g.Explosions = eng.NewParticleGenerator(shaders["particle"], textures["particle"], explosionConfig)
+	g.Sparkles = eng.NewParticleGenerator(shaders["particle"], textures["particle"], sparkleConfig)
+	g.SpriteRenderer = eng.NewSpriteRenderer(shaders["sprite"])
+
+	g.Effects, err = eng.NewPostProcessor(shaders["postprocessing"], g.Width, g.Height, g.WindowOptions.Samples)
+	if err != nil {
+		return err
+	}
+
+	g.Camera = eng.NewCamera2D(width, height)
+	g.Camera.Attach(g.SpriteRenderer, g.Trail, g.Explosions, g.Sparkles)
+	g.Camera.AttachScreen(g.TextRenderer)
+
+	g.Levels, err = breakout.LoadLevels(g.FS, "levels", g.levelTexture, g.Width, int(float32(g.Height)*0.5))
+	if err != nil {
+		return err
+	}
+	g.loadProgress()
+	if g.Watch {
+		g.levelWatcher = eng.NewWatcher(g.FS)
+		for _, level := range g.Levels {
+			g.watchLevel(level)
+		}
+	}
+
+	g.Background = textures["background"]
+	if g.Replay != nil {
+		g.Play(g.Replay)
+	} else if g.RecordFile != "" {
+		g.Record(time.Now().UnixNano(), g.step())
+	} else {
+		g.Seed(time.Now().UnixNano())
+	}
+	g.BrickDestroyed = func(brick *breakout.Object) {
+		g.Explosions.Emit(30, brick.Position.Add(brick.Size.Mul(.5)), mgl32.Vec2{})
+	}
+	if g.EditDir != "" {
+		g.SaveLevel = g.saveLevel
+	}
+
+	window.SetKeyCallback(func(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action == glfw.Press {
+			g.debugKey(key)
+		}
+		// store for continuous application, unless a replay is typing
+		if g.Replaying() {
+			return
+		}
+		if action == glfw.Press {
+			g.SetKey(input.Key(key), true)
+		} else if action == glfw.Release {
+			g.SetKey(input.Key(key), false)
+		}
+	})
+	window.SetCursorPosCallback(func(window *glfw.Window, x, y float64) {
+		// the cursor is in screen coordinates, which are only framebuffer
+		// pixels without display scaling
+		width, height := window.GetSize()
+		fbWidth, fbHeight := window.GetFramebufferSize()
+		if width == 0 || height == 0 || g.Replaying() {
+			return
+		}
+		pixel := mgl32.Vec2{
+			float32(x) * float32(fbWidth) / float32(width),
+			float32(y) * float32(fbHeight) / float32(height),
+		}
+		g.SetCursor(g.Camera.Unproject(pixel))
+	})
+	window.SetMouseButtonCallback(func(window *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		if g.Replaying() {
+			return
+		}
+		if action == glfw.Press {
+			g.SetButton(input.MouseButton(button), true)
+		} else if action == glfw.Release {
+			g.SetButton(input.MouseButton(button), false)
+		}
+	})
+	return nil
+}
+
+// debugKey controls the loop: F9 stops and starts time, F10 steps once
+// while it is stopped and F7 and F8 halve and double its speed.
+func (g *Game) debugKey(key glfw.Key) {
+	if g.Loop == nil {
+		return
+	}
+	switch key {
+	case glfw.KeyF9:
+		g.Loop.Paused = !g.Loop.Paused
+	case glfw.KeyF10:
+		if g.Loop.Paused {
+			g.Loop.StepOnce()
+		}
+	case glfw.KeyF7:
+		if g.Loop.TimeScale > 1./16 {
+			g.Loop.TimeScale /= 2
+		}
+	case glfw.KeyF8:
+		if g.Loop.TimeScale < 4 {
+			g.Loop.TimeScale *= 2
+		}
+	}
+}
+
+// captureCursor hides the cursor over the window while the mouse can move
+// the paddle, which follows where it is on screen.
+func (g *Game) captureCursor() {
+	mode := glfw.CursorNormal
+	if g.State() == breakout.StateActive && g.MouseSensitivity > 0 {
+		mode = glfw.CursorHidden
+	}
+	if mode != g.cursorMode {
+		g.cursorMode = mode
+		g.window.SetInputMode(glfw.CursorMode, mode)
+	}
+}
+
+// step is the timestep the game is updated at.
+func (g *Game) step() float32 {
+	if g.Loop != nil {
+		return float32(g.Loop.Step)
+	}
+	return eng.DefaultStep
+}
+
+// Resize letterboxes the play field into the window's framebuffer, rendering
+// the scene at the size it is shown.
+func (g *Game) Resize(width, height int) {
+	g.Camera.Resize(width, height)
+	_, _, w, h := g.Camera.Viewport()
+	if err := g.Effects.Resize(int(w), int(h)); err != nil {
+		log.Printf("keeping the scene at %dx%d: %v", g.Effects.Width, g.Effects.Height, err)
+	}
+}
+
+func (g *Game) Update(dt float32) {
+	g.LastBallPosition = g.Ball.Position
+	g.LastPlayerPosition = g.Player.Position
+
+	if !g.Replaying() {
+		eng.PollJoystick(g.Simulation, glfw.Joystick1)
+	}
+	g.Simulation.Update(dt)
+	if g.Input.Pressed(breakout.ActionVSync) {
+		g.vsync = 1 - g.vsync
+		glfw.SwapInterval(g.vsync)
+	}
+	g.captureCursor()
+	if g.Quit {
+		g.window.SetShouldClose(true)
+	}
+	if g.State() != breakout.StateActive {
+		g.saveProgress()
+	}
+	if g.State() == breakout.StateActive {
+		ball := g.Ball.Object
+		g.Trail.Spawn(dt, ball.Position.Add(mgl32.Vec2{g.Ball.Radius / 2, g.Ball.Radius / 2}), ball.Velocity)
+		for _, p := range g.PowerUps {
+			if !p.Destroyed {
+				g.Sparkles.Spawn(dt, p.Position.Add(p.Size.Mul(.5)), p.Velocity)
+			}
+		}
+		g.Trail.Update(dt)
+		g.Explosions.Update(dt)
+		g.Sparkles.Update(dt)
+	}
+}
+
+func (g *Game) Render(alpha float32) {
+	g.Reload()
+	g.reloadLevels()
+	g.Camera.Apply()
+
+	g.Effects.Confuse = g.Confuse
+	g.Effects.Chaos = g.Chaos
+	g.Effects.Shake = g.ShakeTime > 0
+	g.Effects.Blur = g.State() == breakout.StatePaused
+
+	g.Effects.Begin()
+	if g.State() == breakout.StateActive {
+		g.renderScene(alpha)
+	} else {
+		g.renderScene(1)
+	}
+	g.Effects.End()
+	g.Effects.Render(glfw.GetTime())
+
+	switch g.State() {
+	case breakout.StateMenu:
+		g.renderMenu()
+	case breakout.StateActive:
+		g.renderActive()
+	case breakout.StatePaused:
+		g.renderPaused()
+	case breakout.StateWin:
+		g.renderWin()
+	case breakout.StateGameOver:
+		g.renderGameOver()
+	case breakout.StateEditor:
+		g.renderEditor()
+	}
+	g.renderLoop()
+}
+
+// renderLoop notes when time isn't passing normally, so a stopped game
+// isn't taken for a hung one.
+func (g *Game) renderLoop() {
+	if g.Loop == nil {
+		return
+	}
+	var note string
+	switch {
+	case g.Loop.Paused:
+		note = fmt.Sprintf("Stepping, tick %d: F10 steps, F9 resumes", g.Loop.Ticks)
+	case g.Loop.TimeScale != 1:
+		note = fmt.Sprintf("Time x%g: F7 and F8 change it", g.Loop.TimeScale)
+	default:
+		return
+	}
+	g.TextRenderer.PrintOptions(note, float32(g.Width)/2, float32(g.Height)-20, hintText)
+}
+
+func (g *Game) renderScene(alpha float32) {
+	g.SpriteRenderer.Begin()
+	background := g.Background
+	if file := g.Levels[g.Level].BackgroundFile; file != "" {
+		if texture, err := g.Texture(file); err == nil {
+			background = texture
+		}
+	}
+	g.SpriteRenderer.DrawSprite(background, breakout.Vec2(0, 0), breakout.Vec2(g.Width, g.Height), 0, eng.DefaultColor)
+	g.drawLevel(g.Levels[g.Level])
+	for _, p := range g.PowerUps {
+		if !p.Destroyed {
+			g.drawObject(p.Object, nil, 0)
+		}
+	}
+	g.drawObject(g.Player, &g.LastPlayerPosition, alpha)
+	g.SpriteRenderer.End()
+	g.Sparkles.Draw()
+	g.Explosions.Draw()
+	g.Trail.Draw()
+	g.drawObject(g.Ball.Object, &g.LastBallPosition, alpha)
+}
+
+// sprite is the texture loaded for a sprite name.
+func (g *Game) sprite(name string) *eng.Texture2D {
+	texture, err := g.Texture(name)
+	if err != nil {
+		return nil
+	}
+	return texture
+}
+
+// drawObject draws o blended from last, if set, to where it is by alpha.
+func (g *Game) drawObject(o *breakout.Object, last *mgl32.Vec2, alpha float32) {
+	pos := o.Position
+	if last != nil {
+		pos = pos.Mul(alpha).Add(last.Mul(1.0 - alpha))
+	}
+	g.SpriteRenderer.DrawSprite(g.sprite(o.Sprite), pos, o.Size, o.Rotation, o.Color)
+}
+
+// drawLevel draws the bricks grouped by sprite so the batching renderer
+// switches textures as little as possible. Bricks never overlap so the order
+// between groups doesn't show.
+func (g *Game) drawLevel(l *breakout.Level) {
+	var sprites []string
+	seen := map[string]bool{}
+	for _, tile := range l.Bricks {
+		if !seen[tile.Sprite] {
+			seen[tile.Sprite] = true
+			sprites = append(sprites, tile.Sprite)
+		}
+	}
+	for _, sprite := range sprites {
+		for _, tile := range l.Bricks {
+			if !tile.Destroyed && tile.Sprite == sprite {
+				g.drawObject(tile, nil, 0)
+			}
+		}
+	}
+}
+
+// key names what action is bound to, for hints.
+func (g *Game) key(action input.Action) string {
+	return g.Input.Bindings.Name(action)
+}
+
+// centerText is the layout for a line of text centered on the screen.
+var centerText = eng.TextOptions{Scale: 1, Align: eng.AlignCenter}
+
+// hintText is centered text at the size used for hints and the HUD.
+var hintText = eng.TextOptions{Scale: .75, Align: eng.AlignCenter}
+
+func (g *Game) renderMenu() {
+	width, height := float32(g.Width), float32(g.Height)
+	g.TextRenderer.PrintOptions(fmt.Sprintf("Press %s to start", g.key(breakout.ActionConfirm)), width/2, height/2, centerText)
+	choose := fmt.Sprintf("Press %s or %s to select level", g.key(breakout.ActionMenuUp), g.key(breakout.ActionMenuDown))
+	g.TextRenderer.PrintOptions(choose, width/2, height/2+25, hintText)
+	level := fmt.Sprintf("Level %d of %d", g.Level+1, len(g.Levels))
+	if name := g.Levels[g.Level].Name; name != "" {
+		level += ": " + name
+	}
+	g.TextRenderer.PrintOptions(level, width/2, height/2+50, hintText)
+	if g.SaveLevel != nil {
+		g.TextRenderer.PrintOptions(fmt.Sprintf("Press %s to edit the level", g.key(breakout.ActionEdit)), width/2, height/2+75, hintText)
+	}
+}
+
+func (g *Game) renderActive() {
+	g.renderHUD()
+	if g.PlayTesting() {
+		back := fmt.Sprintf("Press %s to go back to the editor", g.key(breakout.ActionBack))
+		g.TextRenderer.PrintOptions(back, float32(g.Width)/2, float32(g.Height)-40, hintText)
+	}
+}
+
+// renderHUD draws lives and score on the left, the combo in the middle and
+// the level and high score on the right, along the top of the screen.
+func (g *Game) renderHUD() {
+	width := float32(g.Width)
+	left := eng.TextOptions{Scale: .75}
+	right := eng.TextOptions{Scale: .75, Align: eng.AlignRight}
+	g.TextRenderer.PrintOptions(fmt.Sprintf("Lives: %d   Score: %d", g.Lives, g.Score), 10, 25, left)
+	if g.Combo > 1 {
+		g.TextRenderer.SetColor(1, 1, 0, 1)
+		g.TextRenderer.PrintOptions(fmt.Sprintf("x%d", g.Combo), width/2, 25, hintText)
+		g.TextRenderer.SetColor(1, 1, 1, 1)
+	}
+	g.TextRenderer.PrintOptions(fmt.Sprintf("Level: %d   High score: %d", g.Level+1, g.HighScore), width-10, 25, right)
+}
+
+func (g *Game) renderPaused() {
+	g.renderHUD()
+	width, height := float32(g.Width), float32(g.Height)
+	g.TextRenderer.PrintOptions("Paused", width/2, height/2, centerText)
+	resume := fmt.Sprintf("Press %s to resume or %s to quit to the menu", g.key(breakout.ActionPause), g.key(breakout.ActionQuit))
+	g.TextRenderer.PrintOptions(resume, width/2, height/2+25, hintText)
+}
+
+func (g *Game) renderWin() {
+	width, height := float32(g.Width), float32(g.Height)
+	g.TextRenderer.SetColor(0, 1, 0, 1)
+	g.TextRenderer.PrintOptions("You WON!!!", width/2, height/2-20, centerText)
+	g.TextRenderer.PrintOptions(fmt.Sprintf("Score: %d", g.Score), width/2, height/2+40, hintText)
+	g.TextRenderer.SetColor(1, 1, 0, 1)
+	g.TextRenderer.PrintOptions(fmt.Sprintf("Press %s to return to the menu", g.key(breakout.ActionConfirm)), width/2, height/2+10, hintText)
+	g.TextRenderer.SetColor(1, 1, 1, 1)
+}
+
+func (g *Game) renderGameOver() {
+	width, height := float32(g.Width), float32(g.Height)
+	g.TextRenderer.SetColor(1, 0, 0, 1)
+	g.TextRenderer.PrintOptions("Game Over", width/2, height/2-20, centerText)
+	g.TextRenderer.SetColor(1, 1, 1, 1)
+	g.TextRenderer.PrintOptions(fmt.Sprintf("Score: %d", g.Score), width/2, height/2+40, hintText)
+	g.TextRenderer.PrintOptions(fmt.Sprintf("Press %s to return to the menu", g.key(breakout.ActionConfirm)), width/2, height/2+10, hintText)
+}
+
+// editor colors
+var (
+	gridColor   = mgl32.Vec3{.3, .3, .3}
+	cursorColor = mgl32.Vec3{1, 1, 0}
+)
+
+// renderEditor draws the grid over the level, the brush under the cursor,
+// the palette to pick brushes from and the controls.
+func (g *Game) renderEditor() {
+	e := g.Editor
+	level := e.Level
+	block := g.sprite(breakout.BlockSprite)
+	columns, rows := e.Size()
+	tile := level.TileSize()
+	width, height := tile.X()*float32(columns), tile.Y()*float32(rows)
+
+	g.SpriteRenderer.Begin()
+	for x := 0; x <= columns; x++ {
+		g.SpriteRenderer.DrawSprite(block, mgl32.Vec2{tile.X() * float32(x), 0}, mgl32.Vec2{1, height}, 0, gridColor)
+	}
+	for y := 0; y <= rows; y++ {
+		g.SpriteRenderer.DrawSprite(block, mgl32.Vec2{0, tile.Y() * float32(y)}, mgl32.Vec2{width, 1}, 0, gridColor)
+	}
+	if column, row, ok := level.TileAt(g.Cursor); ok {
+		pos := mgl32.Vec2{tile.X() * float32(column), tile.Y() * float32(row)}
+		if brick, ok := level.Palette[e.Brush]; ok {
+			g.SpriteRenderer.DrawSprite(g.sprite(level.Sprite(e.Brush)), pos, tile, 0, brick.Color)
+		} else {
+			g.SpriteRenderer.DrawSprite(block, pos, tile, 0, mgl32.Vec3{})
+		}
+	}
+
+	// the palette, numbered for the keys that pick from it
+	swatch := mgl32.Vec2{40, 20}
+	_, levelHeight := level.Size()
+	top := float32(levelHeight) + 40
+	brushes := e.Brushes()
+	for i, key := range brushes {
+		pos := mgl32.Vec2{10 + float32(i)*(swatch.X()+10), top}
+		if key == e.Brush {
+			g.SpriteRenderer.DrawSprite(block, pos.Sub(mgl32.Vec2{3, 3}), swatch.Add(mgl32.Vec2{6, 6}), 0, cursorColor)
+		}
+		if brick, ok := level.Palette[key]; ok {
+			g.SpriteRenderer.DrawSprite(g.sprite(level.Sprite(key)), pos, swatch, 0, brick.Color)
+		} else {
+			g.SpriteRenderer.DrawSprite(block, pos, swatch, 0, mgl32.Vec3{})
+		}
+	}
+	g.SpriteRenderer.End()
+
+	small := eng.TextOptions{Scale: .5}
+	for i, key := range brushes {
+		x := 10 + float32(i)*(swatch.X()+10)
+		label := key
+		if i < breakout.BrushActions {
+			label = fmt.Sprintf("%s: %s", g.key(breakout.ActionBrush(i)), key)
+		}
+		g.TextRenderer.PrintOptions(label, x, top+swatch.Y()+15, small)
+	}
+
+	w, h := float32(g.Width), float32(g.Height)
+	g.TextRenderer.PrintOptions(fmt.Sprintf("Editing %s, %d by %d", level.File(), columns, rows), w/2, h-90, hintText)
+	pick := fmt.Sprintf("%s paints, %s erases, %s to %s or %s and %s pick a brush",
+		g.key(breakout.ActionPaint), g.key(breakout.ActionErase), g.key(breakout.ActionBrush(0)), g.key(breakout.ActionBrush(breakout.BrushActions-1)),
+		g.key(breakout.ActionBrushPrevious), g.key(breakout.ActionBrushNext))
+	g.TextRenderer.PrintOptions(pick, w/2, h-65, hintText)
+	commands := fmt.Sprintf("%s %s %s %s resize, %s undo, %s redo, %s save, %s play, %s menu",
+		g.key(breakout.ActionRemoveColumn), g.key(breakout.ActionAddColumn), g.key(breakout.ActionRemoveRow), g.key(breakout.ActionAddRow),
+		g.key(breakout.ActionUndo), g.key(breakout.ActionRedo), g.key(breakout.ActionSave), g.key(breakout.ActionConfirm), g.key(breakout.ActionBack))
+	g.TextRenderer.PrintOptions(commands, w/2, h-40, hintText)
+	if e.Status != "" {
+		g.TextRenderer.SetColor(1, 1, 0, 1)
+		g.TextRenderer.PrintOptions(e.Status, w/2, h-115, hintText)
+		g.TextRenderer.SetColor(1, 1, 1, 1)
+	}
+}
+
+// saveLevel writes the level being edited to EditDir, under the path its
+// SaveFile has.
+func (g *Game) saveLevel(level *breakout.Level) error {
+	path := filepath.Join(g.EditDir, filepath.FromSlash(level.SaveFile()))
+	if err := level.Save(path); err != nil {
+		log.Printf("failed to save level: %v", err)
+		return err
+	}
+	log.Printf("saved level %s", path)
+	return nil
+}
+
+// reloadLevels loads levels again when their files change. Only the bricks
+// are rebuilt, the paddle, ball and score carry on. A level that fails to
+// load is logged and left as it was.
+func (g *Game) reloadLevels() {
+	if g.levelWatcher == nil || time.Since(g.lastLevelPoll) < watchInterval {
+		return
+	}
+	g.lastLevelPoll = time.Now()
+
+	for _, file := range g.levelWatcher.Changed() {
+		for _, level := range g.Levels {
+			if level.File() != file && level.SaveFile() != file {
+				continue
+			}
+			if err := level.Load(g.FS, level.File(), g.Width, int(float32(g.Height)*0.5)); err != nil {
+				log.Printf("keeping level %s: %v", file, err)
+				continue
+			}
+			log.Printf("reloaded level %s", file)
+			if g.Editor != nil && g.Editor.Level == level {
+				g.Editor.Reloaded()
+			}
+		}
+	}
+}
+
+// watchLevel watches the files a level is loaded from: its own and the
+// one the editor saves it to, which may not exist yet.
+func (g *Game) watchLevel(level *breakout.Level) {
+	for _, file := range []string{level.File(), level.SaveFile()} {
+		if !g.levelWatcher.Watching(file) {
+			g.levelWatcher.Add(file)
+		}
+	}
+}
+
+// levelTexture loads a texture named in a level file, once, as the sprite
+// named by its path.
+func (g *Game) levelTexture(path string) error {
+	if _, err := g.Texture(path); err == nil {
+		return nil
+	}
+	_, err := g.LoadTexture(path, path)
+	return err
+}
+
+// loadProgress restores progress from ProgressFile. The game starts fresh if
+// it can't be read.
+func (g *Game) loadProgress() {
+	if g.ProgressFile == "" {
+		file, err := breakout.DefaultProgressFile()
+		if err != nil {
+			log.Printf("not saving progress: %v", err)
+			return
+		}
+		g.ProgressFile = file
+	}
+	progress, err := breakout.LoadProgress(g.ProgressFile)
+	if err != nil {
+		log.Printf("ignoring saved progress: %v", err)
+	}
+	g.SetProgress(progress)
+	g.saved = g.Progress()
+}
+
+// saveProgress writes progress to ProgressFile if it changed since the last
+// save. It is only called between games so play doesn't wait on the disk.
+func (g *Game) saveProgress() {
+	progress := g.Progress()
+	if g.ProgressFile == "" || progress == g.saved || g.Replay != nil {
+		return
+	}
+	if err := progress.Save(g.ProgressFile); err != nil {
+		log.Printf("failed to save progress: %v", err)
+	}
+	g.saved = progress
+}
+
+func (g *Game) Close() {
+	g.saveProgress()
+	if g.Recording != nil {
+		g.Recording.Ticks = g.Tick
+		if err := g.Recording.Save(g.RecordFile); err != nil {
+			log.Printf("failed to save replay: %v", err)
+		}
+	}
+	g.Effects.Destroy()
+	g.SpriteRenderer.Destroy()
+	g.Trail.Destroy()
+	g.Explosions.Destroy()
+	g.Sparkles.Destroy()
+	g.TextRenderer.Destroy()
+	g.Clear()
+}
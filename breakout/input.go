@@ -0,0 +1,110 @@
+package breakout
+
+import (
+	"fmt"
+
+	"github.com/jakecoffman/learnopengl/breakout/input"
+)
+
+// The actions the game is played with, as named in a config file's
+// bindings.
+const (
+	ActionLeft     input.Action = "move-left"
+	ActionRight    input.Action = "move-right"
+	ActionLaunch   input.Action = "launch"
+	ActionPause    input.Action = "pause"
+	ActionConfirm  input.Action = "confirm"
+	ActionBack     input.Action = "back"
+	ActionMenuUp   input.Action = "menu-up"
+	ActionMenuDown input.Action = "menu-down"
+	ActionQuit     input.Action = "quit"
+	ActionEdit     input.Action = "edit"
+	ActionVSync    input.Action = "vsync"
+
+	// the level editor's, which plays the level with ActionConfirm and
+	// leaves with ActionBack
+	ActionPaint         input.Action = "paint"
+	ActionErase         input.Action = "erase"
+	ActionBrushPrevious input.Action = "brush-previous"
+	ActionBrushNext     input.Action = "brush-next"
+	ActionAddColumn     input.Action = "add-column"
+	ActionRemoveColumn  input.Action = "remove-column"
+	ActionAddRow        input.Action = "add-row"
+	ActionRemoveRow     input.Action = "remove-row"
+	ActionUndo          input.Action = "undo"
+	ActionRedo          input.Action = "redo"
+	ActionSave          input.Action = "save"
+)
+
+// BrushActions is how many of the editor's brushes have an action to pick
+// them.
+const BrushActions = 9
+
+// ActionBrush picks the editor's i-th brush, counting from 0, e.g.
+// "brush-1" for the first.
+func ActionBrush(i int) input.Action {
+	return input.Action(fmt.Sprint("brush-", i+1))
+}
+
+// DefaultBindings are the keys, the mouse buttons, and the buttons and left
+// stick of an Xbox style gamepad as glfw numbers them, that the actions
+// start bound to. The editor's brushes are on the number keys.
+func DefaultBindings() input.Bindings {
+	bindings := input.Bindings{
+		ActionLeft:     {input.BindKey(input.KeyA), input.BindKey(input.KeyLeft), input.BindJoystickAxis(0, -1)},
+		ActionRight:    {input.BindKey(input.KeyD), input.BindKey(input.KeyRight), input.BindJoystickAxis(0, 1)},
+		ActionLaunch:   {input.BindKey(input.KeySpace), input.BindMouseButton(input.MouseButtonLeft), input.BindJoystickButton(0)},
+		ActionPause:    {input.BindKey(input.KeyP), input.BindKey(input.KeyEscape), input.BindJoystickButton(7)},
+		ActionConfirm:  {input.BindKey(input.KeyEnter), input.BindJoystickButton(0), input.BindJoystickButton(7)},
+		ActionBack:     {input.BindKey(input.KeyEscape), input.BindJoystickButton(6)},
+		ActionMenuUp:   {input.BindKey(input.KeyW), input.BindKey(input.KeyUp), input.BindJoystickAxis(1, -1)},
+		ActionMenuDown: {input.BindKey(input.KeyS), input.BindKey(input.KeyDown), input.BindJoystickAxis(1, 1)},
+		ActionQuit:     {input.BindKey(input.KeyQ), input.BindJoystickButton(6)},
+		ActionEdit:     {input.BindKey(input.KeyE)},
+		ActionVSync:    {input.BindKey(input.KeyV)},
+
+		ActionPaint:         {input.BindMouseButton(input.MouseButtonLeft)},
+		ActionErase:         {input.BindMouseButton(input.MouseButtonRight)},
+		ActionBrushPrevious: {input.BindKey(input.KeyLeftBracket)},
+		ActionBrushNext:     {input.BindKey(input.KeyRightBracket)},
+		ActionAddColumn:     {input.BindKey(input.KeyRight)},
+		ActionRemoveColumn:  {input.BindKey(input.KeyLeft)},
+		ActionAddRow:        {input.BindKey(input.KeyDown)},
+		ActionRemoveRow:     {input.BindKey(input.KeyUp)},
+		ActionUndo:          {input.BindKey(input.KeyZ)},
+		ActionRedo:          {input.BindKey(input.KeyY)},
+		ActionSave:          {input.BindKey(input.KeyS)},
+	}
+	for i := 0; i < BrushActions; i++ {
+		bindings[ActionBrush(i)] = []input.Binding{input.BindKey(input.Key1 + input.Key(i))}
+	}
+	return bindings
+}
+
+// Bind rebinds the actions in bindings, leaving the rest as they are.
+func (s *Simulation) Bind(bindings input.Bindings) {
+	for action, b := range bindings {
+		s.Input.Bindings[action] = b
+	}
+}
+
+// Controls are how the paddle responds to the mouse and analog sticks.
+type Controls struct {
+	// MouseSensitivity scales how fast the paddle follows the mouse cursor,
+	// 1 if left at 0. Negative leaves the mouse out of it.
+	MouseSensitivity float32 `json:"mouseSensitivity"`
+	// Deadzone is how far from the center a stick is ignored, from 0 to 1,
+	// or the default if 0.
+	Deadzone float32 `json:"deadzone"`
+}
+
+// SetControls applies c to the paddle.
+func (s *Simulation) SetControls(c Controls) {
+	s.MouseSensitivity = c.MouseSensitivity
+	if s.MouseSensitivity == 0 {
+		s.MouseSensitivity = 1
+	}
+	if c.Deadzone > 0 {
+		s.Input.Deadzone = c.Deadzone
+	}
+}
@@ -0,0 +1,368 @@
+// Package input maps named actions to keys, mouse buttons and joysticks. It
+// doesn't depend on glfw or GL so a simulation reading it runs headless.
+package input
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Action names something the player does, like moving left or pausing,
+// whatever it is bound to.
+type Action string
+
+// InputKind is what sort of input a Binding is to.
+type InputKind int
+
+const (
+	KeyInput InputKind = iota
+	MouseInput
+	JoystickButtonInput
+	JoystickAxisInput
+)
+
+// Binding is one input an action is bound to. It reads as text like "A",
+// "Space", "MouseLeft", "Button7" for a joystick button or "Axis0-" for a
+// joystick axis pushed towards its negative end.
+type Binding struct {
+	Kind InputKind
+	// Code is the key, mouse button, joystick button or joystick axis.
+	Code int
+	// Direction is the end of a joystick axis bound, -1 or 1.
+	Direction int
+}
+
+// BindKey binds a key.
+func BindKey(key Key) Binding {
+	return Binding{Kind: KeyInput, Code: int(key)}
+}
+
+// BindMouseButton binds a mouse button.
+func BindMouseButton(button MouseButton) Binding {
+	return Binding{Kind: MouseInput, Code: int(button)}
+}
+
+// BindJoystickButton binds a joystick button by its index.
+func BindJoystickButton(button int) Binding {
+	return Binding{Kind: JoystickButtonInput, Code: button}
+}
+
+// BindJoystickAxis binds a joystick axis, by its index, pushed towards one
+// end: -1 for left or up on a stick and 1 for right or down.
+func BindJoystickAxis(axis, direction int) Binding {
+	return Binding{Kind: JoystickAxisInput, Code: axis, Direction: direction}
+}
+
+func (b Binding) String() string {
+	switch b.Kind {
+	case KeyInput:
+		return Key(b.Code).String()
+	case MouseInput:
+		return MouseButton(b.Code).String()
+	case JoystickButtonInput:
+		return fmt.Sprint("Button", b.Code)
+	case JoystickAxisInput:
+		if b.Direction < 0 {
+			return fmt.Sprint("Axis", b.Code, "-")
+		}
+		return fmt.Sprint("Axis", b.Code, "+")
+	}
+	return fmt.Sprintf("Binding(%d, %d)", b.Kind, b.Code)
+}
+
+// Name names what action is bound to for a hint on screen, e.g. "Enter": its
+// first key or mouse button, or its first binding if it is only on a
+// joystick. An action bound to nothing is "nothing".
+func (b Bindings) Name(action Action) string {
+	for _, binding := range b[action] {
+		if binding.Kind == KeyInput || binding.Kind == MouseInput {
+			return binding.String()
+		}
+	}
+	if len(b[action]) > 0 {
+		return b[action][0].String()
+	}
+	return "nothing"
+}
+
+// ParseBinding reads a binding written as String writes it.
+func ParseBinding(name string) (Binding, error) {
+	// an index after a prefix, e.g. the 7 of Button7
+	index := func(prefix string, max int) (int, bool) {
+		n, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+		return n, err == nil && n >= 0 && n < max
+	}
+	switch {
+	case len(name) == 1 && name[0] >= 'A' && name[0] <= 'Z':
+		return BindKey(KeyA + Key(name[0]-'A')), nil
+	case len(name) == 1 && name[0] >= '0' && name[0] <= '9':
+		return BindKey(Key0 + Key(name[0]-'0')), nil
+	case strings.HasPrefix(name, "F"):
+		if n, ok := index("F", 26); ok && n > 0 {
+			return BindKey(KeyF1 + Key(n-1)), nil
+		}
+	case strings.HasPrefix(name, "KP"):
+		if n, ok := index("KP", 10); ok {
+			return BindKey(KeyKP0 + Key(n)), nil
+		}
+	case strings.HasPrefix(name, "Mouse"):
+		if n, ok := index("Mouse", int(MouseButtonLast)+2); ok && n > 0 {
+			return BindMouseButton(MouseButton(n - 1)), nil
+		}
+		for button, n := range mouseNames {
+			if n == name {
+				return BindMouseButton(button), nil
+			}
+		}
+	case strings.HasPrefix(name, "Button"):
+		if n, ok := index("Button", MaxJoystickButtons); ok {
+			return BindJoystickButton(n), nil
+		}
+	case strings.HasPrefix(name, "Axis") && (strings.HasSuffix(name, "-") || strings.HasSuffix(name, "+")):
+		direction := 1
+		if strings.HasSuffix(name, "-") {
+			direction = -1
+		}
+		name = name[:len(name)-1]
+		if n, ok := index("Axis", MaxJoystickAxes); ok {
+			return BindJoystickAxis(n, direction), nil
+		}
+	}
+	for key, n := range keyNames {
+		if n == name {
+			return BindKey(key), nil
+		}
+	}
+	return Binding{}, fmt.Errorf("unknown input %q", name)
+}
+
+func (b Binding) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *Binding) UnmarshalText(text []byte) error {
+	binding, err := ParseBinding(string(text))
+	if err != nil {
+		return err
+	}
+	*b = binding
+	return nil
+}
+
+// Bindings maps actions to the inputs that do them, any of which will. As
+// JSON it is an object of actions to lists of bindings, e.g.
+//
+//	{"move-left": ["A", "Left", "Axis0-"]}
+type Bindings map[Action][]Binding
+
+const (
+	// MaxJoystickButtons and MaxJoystickAxes are how many of a joystick's
+	// buttons and axes are read.
+	MaxJoystickButtons = 32
+	MaxJoystickAxes    = 16
+	// axisThreshold is how far an axis is pushed to hold the action bound
+	// to it, as if it were a button.
+	axisThreshold = .5
+)
+
+// digital is a key or button's state: whether it is held, whether it went
+// down since the last tick, and whether it went down just before this one.
+// Going down is latched until the tick so a tap between two ticks isn't
+// missed.
+type digital struct {
+	held, latched, pressed bool
+}
+
+func (d *digital) set(down bool) {
+	if down && !d.held {
+		d.latched = true
+	}
+	d.held = down
+}
+
+func (d *digital) tick() {
+	d.pressed, d.latched = d.latched, false
+}
+
+// Input reads actions off the keyboard, mouse and a joystick. Window
+// callbacks, or a replay, feed it with SetKey and friends as events happen
+// and Update marks the ticks, so that Pressed is true for the one tick after
+// an action's input goes down.
+type Input struct {
+	Bindings Bindings
+	// Deadzone is how far from the center a joystick axis is ignored, from
+	// 0 to 1, as sticks rarely rest exactly centered.
+	Deadzone float32
+
+	keys       [KeyLast + 1]digital
+	buttons    [MouseButtonLast + 1]digital
+	joyButtons [MaxJoystickButtons]digital
+	// axes as last set, as of this tick and as of the last one
+	axes, tickAxes, lastAxes [MaxJoystickAxes]float32
+}
+
+// NewInput creates an input reading actions through bindings.
+func NewInput(bindings Bindings) *Input {
+	return &Input{Bindings: bindings, Deadzone: .2}
+}
+
+// SetKey records a key going down or up.
+func (in *Input) SetKey(key Key, down bool) {
+	if key >= 0 && int(key) < len(in.keys) {
+		in.keys[key].set(down)
+	}
+}
+
+// SetButton records a mouse button going down or up.
+func (in *Input) SetButton(button MouseButton, down bool) {
+	if button >= 0 && int(button) < len(in.buttons) {
+		in.buttons[button].set(down)
+	}
+}
+
+// SetJoystickButton records a joystick button going down or up.
+func (in *Input) SetJoystickButton(button int, down bool) {
+	if button >= 0 && button < len(in.joyButtons) {
+		in.joyButtons[button].set(down)
+	}
+}
+
+// SetJoystickAxis records where a joystick axis is, from -1 to 1.
+func (in *Input) SetJoystickAxis(axis int, value float32) {
+	if axis >= 0 && axis < len(in.axes) {
+		in.axes[axis] = value
+	}
+}
+
+// JoystickButtonDown reports whether a joystick button is held.
+func (in *Input) JoystickButtonDown(button int) bool {
+	return in.down(BindJoystickButton(button))
+}
+
+// JoystickAxis is where a joystick axis was last set to.
+func (in *Input) JoystickAxis(axis int) float32 {
+	if axis < 0 || axis >= len(in.axes) {
+		return 0
+	}
+	return in.axes[axis]
+}
+
+// Update starts a tick: inputs that went down since the last one are
+// pressed until the next.
+func (in *Input) Update() {
+	for i := range in.keys {
+		in.keys[i].tick()
+	}
+	for i := range in.buttons {
+		in.buttons[i].tick()
+	}
+	for i := range in.joyButtons {
+		in.joyButtons[i].tick()
+	}
+	in.lastAxes, in.tickAxes = in.tickAxes, in.axes
+}
+
+// axis is how far axis is pushed towards direction, past the deadzone and
+// scaled from 0 to 1.
+func (in *Input) axis(axes *[MaxJoystickAxes]float32, axis, direction int) float32 {
+	if axis < 0 || axis >= len(axes) {
+		return 0
+	}
+	value := axes[axis] * float32(direction)
+	if value <= in.Deadzone {
+		return 0
+	}
+	if value >= 1 {
+		return 1
+	}
+	return (value - in.Deadzone) / (1 - in.Deadzone)
+}
+
+// button is the state of the key or button b binds, nil for an axis.
+func (in *Input) button(b Binding) *digital {
+	var buttons []digital
+	switch b.Kind {
+	case KeyInput:
+		buttons = in.keys[:]
+	case MouseInput:
+		buttons = in.buttons[:]
+	case JoystickButtonInput:
+		buttons = in.joyButtons[:]
+	}
+	if b.Code < 0 || b.Code >= len(buttons) {
+		return nil
+	}
+	return &buttons[b.Code]
+}
+
+func (in *Input) down(b Binding) bool {
+	if b.Kind == JoystickAxisInput {
+		return in.axis(&in.tickAxes, b.Code, b.Direction) >= axisThreshold
+	}
+	d := in.button(b)
+	return d != nil && d.held
+}
+
+func (in *Input) pressed(b Binding) bool {
+	if b.Kind == JoystickAxisInput {
+		return in.down(b) && in.axis(&in.lastAxes, b.Code, b.Direction) < axisThreshold
+	}
+	d := in.button(b)
+	return d != nil && d.pressed
+}
+
+// Down reports whether any input bound to action is held.
+func (in *Input) Down(action Action) bool {
+	for _, b := range in.Bindings[action] {
+		if in.down(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// Pressed reports whether any input bound to action went down just before
+// this tick, so holding it does the action once.
+func (in *Input) Pressed(action Action) bool {
+	for _, b := range in.Bindings[action] {
+		if in.pressed(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// Value is how far action is done, from 0 to 1: 1 for a held key or
+// button, or how far a joystick axis bound to it is pushed.
+func (in *Input) Value(action Action) float32 {
+	var value float32
+	for _, b := range in.Bindings[action] {
+		var v float32
+		switch {
+		case b.Kind == JoystickAxisInput:
+			v = in.axis(&in.tickAxes, b.Code, b.Direction)
+		case in.down(b):
+			v = 1
+		}
+		if v > value {
+			value = v
+		}
+	}
+	return value
+}
+
+// KeyDown reports whether key is held, for input that isn't an action.
+func (in *Input) KeyDown(key Key) bool {
+	return in.down(BindKey(key))
+}
+
+// KeyPressed reports whether key went down just before this tick.
+func (in *Input) KeyPressed(key Key) bool {
+	return in.pressed(BindKey(key))
+}
+
+// ButtonDown reports whether a mouse button is held.
+func (in *Input) ButtonDown(button MouseButton) bool {
+	return in.down(BindMouseButton(button))
+}
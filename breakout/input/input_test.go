@@ -0,0 +1,145 @@
+package input
+
+import "testing"
+
+func TestBindingsName(t *testing.T) {
+	bindings := Bindings{
+		"keys":     {BindKey(KeyW), BindKey(KeyUp)},
+		"stick":    {BindJoystickAxis(1, -1), BindKey(KeyEnter)},
+		"mouse":    {BindJoystickButton(0), BindMouseButton(MouseButtonLeft)},
+		"joystick": {BindJoystickButton(7), BindJoystickButton(0)},
+		"empty":    {},
+	}
+	tests := []struct {
+		action Action
+		want   string
+	}{
+		{"keys", "W"},
+		{"stick", "Enter"},
+		{"mouse", "MouseLeft"},
+		{"joystick", "Button7"},
+		{"empty", "nothing"},
+		{"unbound", "nothing"},
+	}
+	for _, test := range tests {
+		if got := bindings.Name(test.action); got != test.want {
+			t.Errorf("Name(%q) = %q, want %q", test.action, got, test.want)
+		}
+	}
+}
+
+func TestPressed(t *testing.T) {
+	// a step sets the action's input down or up, then maybe starts a tick
+	type step struct {
+		down, tick    bool
+		held, pressed bool
+	}
+	tap := []step{
+		{down: true, tick: true, held: true, pressed: true},
+		{down: true, tick: true, held: true},
+		{down: false, tick: true},
+		{down: true, tick: true, held: true, pressed: true},
+		{down: false, tick: false, held: false, pressed: true},
+		{down: false, tick: true},
+	}
+	// down and up between two ticks still presses once
+	between := []step{
+		{down: true, held: true},
+		{down: false, tick: true, pressed: true},
+		{down: false, tick: true},
+	}
+	tests := []struct {
+		name    string
+		binding Binding
+		set     func(in *Input, down bool)
+		steps   []step
+	}{
+		{"key", BindKey(KeySpace), func(in *Input, down bool) { in.SetKey(KeySpace, down) }, tap},
+		{"key between ticks", BindKey(KeySpace), func(in *Input, down bool) { in.SetKey(KeySpace, down) }, between},
+		{"mouse", BindMouseButton(MouseButtonLeft), func(in *Input, down bool) { in.SetButton(MouseButtonLeft, down) }, tap},
+		{"joystick button", BindJoystickButton(3), func(in *Input, down bool) { in.SetJoystickButton(3, down) }, tap},
+		{"joystick axis", BindJoystickAxis(1, -1), func(in *Input, down bool) {
+			value := float32(0)
+			if down {
+				value = -1
+			}
+			in.SetJoystickAxis(1, value)
+		}, []step{
+			// axes are read as of the tick, so they never press in between
+			{down: true, tick: true, held: true, pressed: true},
+			{down: true, tick: true, held: true},
+			{down: false, tick: true},
+			{down: true, tick: false},
+			{down: true, tick: true, held: true, pressed: true},
+		}},
+	}
+	for _, test := range tests {
+		in := NewInput(Bindings{"act": {test.binding}})
+		for i, s := range test.steps {
+			test.set(in, s.down)
+			if s.tick {
+				in.Update()
+			}
+			if got := in.Down("act"); got != s.held {
+				t.Errorf("%s step %d: Down = %v, want %v", test.name, i, got, s.held)
+			}
+			if got := in.Pressed("act"); got != s.pressed {
+				t.Errorf("%s step %d: Pressed = %v, want %v", test.name, i, got, s.pressed)
+			}
+		}
+	}
+}
+
+func TestDeadzone(t *testing.T) {
+	tests := []struct {
+		value, want float32
+		held        bool
+	}{
+		{0, 0, false},
+		{.25, 0, false},
+		{.5, 0, false},
+		{-1, 0, false},
+		{.625, .25, false},
+		{.75, .5, true},
+		{1, 1, true},
+		{1.5, 1, true},
+	}
+	for _, test := range tests {
+		in := NewInput(Bindings{"right": {BindJoystickAxis(0, 1)}})
+		in.Deadzone = .5
+		in.SetJoystickAxis(0, test.value)
+		in.Update()
+		if got := in.Value("right"); got != test.want {
+			t.Errorf("axis at %v: Value = %v, want %v", test.value, got, test.want)
+		}
+		if got := in.Down("right"); got != test.held {
+			t.Errorf("axis at %v: Down = %v, want %v", test.value, got, test.held)
+		}
+	}
+}
+
+func TestParseBinding(t *testing.T) {
+	for _, b := range []Binding{
+		BindKey(KeyA), BindKey(Key9), BindKey(KeyF1), BindKey(KeyF25), BindKey(KeyKP0), BindKey(KeyEnter),
+		BindMouseButton(MouseButtonLeft), BindMouseButton(MouseButtonLast),
+		BindJoystickButton(0), BindJoystickButton(MaxJoystickButtons - 1),
+		BindJoystickAxis(0, -1), BindJoystickAxis(MaxJoystickAxes-1, 1),
+	} {
+		if got, err := ParseBinding(b.String()); err != nil || got != b {
+			t.Errorf("ParseBinding(%q) = %v, %v, want %v", b.String(), got, err, b)
+		}
+	}
+
+	for _, name := range []string{
+		"", "a", "Space ", "Nope",
+		"F0", "F26", "Fx",
+		"KP10", "KP-1",
+		"Mouse0", "Mouse9", "MouseMiddle1",
+		"Button32", "Button-1", "Button",
+		"Axis0", "Axis16+", "Axis-1-", "Axis+",
+	} {
+		if b, err := ParseBinding(name); err == nil {
+			t.Errorf("ParseBinding(%q) = %v, want an error", name, b)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package input
+
+import "fmt"
+
+// Key is a keyboard key, numbered as GLFW numbers them so a key from a glfw
+// callback converts with Key(key).
+type Key int
+
+// Keys, as GLFW has them.
+const (
+	KeyUnknown      Key = -1
+	KeySpace        Key = 32
+	KeyApostrophe   Key = 39
+	KeyComma        Key = 44
+	KeyMinus        Key = 45
+	KeyPeriod       Key = 46
+	KeySlash        Key = 47
+	Key0            Key = 48
+	Key1            Key = 49
+	Key2            Key = 50
+	Key3            Key = 51
+	Key4            Key = 52
+	Key5            Key = 53
+	Key6            Key = 54
+	Key7            Key = 55
+	Key8            Key = 56
+	Key9            Key = 57
+	KeySemicolon    Key = 59
+	KeyEqual        Key = 61
+	KeyA            Key = 65
+	KeyB            Key = 66
+	KeyC            Key = 67
+	KeyD            Key = 68
+	KeyE            Key = 69
+	KeyF            Key = 70
+	KeyG            Key = 71
+	KeyH            Key = 72
+	KeyI            Key = 73
+	KeyJ            Key = 74
+	KeyK            Key = 75
+	KeyL            Key = 76
+	KeyM            Key = 77
+	KeyN            Key = 78
+	KeyO            Key = 79
+	KeyP            Key = 80
+	KeyQ            Key = 81
+	KeyR            Key = 82
+	KeyS            Key = 83
+	KeyT            Key = 84
+	KeyU            Key = 85
+	KeyV            Key = 86
+	KeyW            Key = 87
+	KeyX            Key = 88
+	KeyY            Key = 89
+	KeyZ            Key = 90
+	KeyLeftBracket  Key = 91
+	KeyBackslash    Key = 92
+	KeyRightBracket Key = 93
+	KeyGraveAccent  Key = 96
+	KeyWorld1       Key = 161
+	KeyWorld2       Key = 162
+	KeyEscape       Key = 256
+	KeyEnter        Key = 257
+	KeyTab          Key = 258
+	KeyBackspace    Key = 259
+	KeyInsert       Key = 260
+	KeyDelete       Key = 261
+	KeyRight        Key = 262
+	KeyLeft         Key = 263
+	KeyDown         Key = 264
+	KeyUp           Key = 265
+	KeyPageUp       Key = 266
+	KeyPageDown     Key = 267
+	KeyHome         Key = 268
+	KeyEnd          Key = 269
+	KeyCapsLock     Key = 280
+	KeyScrollLock   Key = 281
+	KeyNumLock      Key = 282
+	KeyPrintScreen  Key = 283
+	KeyPause        Key = 284
+	KeyF1           Key = 290
+	KeyF2           Key = 291
+	KeyF3           Key = 292
+	KeyF4           Key = 293
+	KeyF5           Key = 294
+	KeyF6           Key = 295
+	KeyF7           Key = 296
+	KeyF8           Key = 297
+	KeyF9           Key = 298
+	KeyF10          Key = 299
+	KeyF11          Key = 300
+	KeyF12          Key = 301
+	KeyF25          Key = 314
+	KeyKP0          Key = 320
+	KeyKP9          Key = 329
+	KeyKPDecimal    Key = 330
+	KeyKPDivide     Key = 331
+	KeyKPMultiply   Key = 332
+	KeyKPSubtract   Key = 333
+	KeyKPAdd        Key = 334
+	KeyKPEnter      Key = 335
+	KeyKPEqual      Key = 336
+	KeyLeftShift    Key = 340
+	KeyLeftControl  Key = 341
+	KeyLeftAlt      Key = 342
+	KeyLeftSuper    Key = 343
+	KeyRightShift   Key = 344
+	KeyRightControl Key = 345
+	KeyRightAlt     Key = 346
+	KeyRightSuper   Key = 347
+	KeyMenu         Key = 348
+	KeyLast             = KeyMenu
+)
+
+// MouseButton is a mouse button, numbered as GLFW numbers them.
+type MouseButton int
+
+const (
+	MouseButtonLeft   MouseButton = 0
+	MouseButtonRight  MouseButton = 1
+	MouseButtonMiddle MouseButton = 2
+	MouseButtonLast   MouseButton = 7
+)
+
+// keyNames are the names of keys that aren't a letter, digit or function
+// key, which are named for themselves.
+var keyNames = map[Key]string{
+	KeySpace:        "Space",
+	KeyApostrophe:   "Apostrophe",
+	KeyComma:        "Comma",
+	KeyMinus:        "Minus",
+	KeyPeriod:       "Period",
+	KeySlash:        "Slash",
+	KeySemicolon:    "Semicolon",
+	KeyEqual:        "Equal",
+	KeyLeftBracket:  "LeftBracket",
+	KeyBackslash:    "Backslash",
+	KeyRightBracket: "RightBracket",
+	KeyGraveAccent:  "GraveAccent",
+	KeyWorld1:       "World1",
+	KeyWorld2:       "World2",
+	KeyEscape:       "Escape",
+	KeyEnter:        "Enter",
+	KeyTab:          "Tab",
+	KeyBackspace:    "Backspace",
+	KeyInsert:       "Insert",
+	KeyDelete:       "Delete",
+	KeyRight:        "Right",
+	KeyLeft:         "Left",
+	KeyDown:         "Down",
+	KeyUp:           "Up",
+	KeyPageUp:       "PageUp",
+	KeyPageDown:     "PageDown",
+	KeyHome:         "Home",
+	KeyEnd:          "End",
+	KeyCapsLock:     "CapsLock",
+	KeyScrollLock:   "ScrollLock",
+	KeyNumLock:      "NumLock",
+	KeyPrintScreen:  "PrintScreen",
+	KeyPause:        "Pause",
+	KeyKPDecimal:    "KPDecimal",
+	KeyKPDivide:     "KPDivide",
+	KeyKPMultiply:   "KPMultiply",
+	KeyKPSubtract:   "KPSubtract",
+	KeyKPAdd:        "KPAdd",
+	KeyKPEnter:      "KPEnter",
+	KeyKPEqual:      "KPEqual",
+	KeyLeftShift:    "LeftShift",
+	KeyLeftControl:  "LeftControl",
+	KeyLeftAlt:      "LeftAlt",
+	KeyLeftSuper:    "LeftSuper",
+	KeyRightShift:   "RightShift",
+	KeyRightControl: "RightControl",
+	KeyRightAlt:     "RightAlt",
+	KeyRightSuper:   "RightSuper",
+	KeyMenu:         "Menu",
+}
+
+var mouseNames = map[MouseButton]string{
+	MouseButtonLeft:   "MouseLeft",
+	MouseButtonRight:  "MouseRight",
+	MouseButtonMiddle: "MouseMiddle",
+}
+
+// String names the key as bindings do, e.g. "A", "7", "F1" or "Escape".
+func (k Key) String() string {
+	switch {
+	case k >= KeyA && k <= KeyZ:
+		return string(rune('A' + k - KeyA))
+	case k >= Key0 && k <= Key9:
+		return string(rune('0' + k - Key0))
+	case k >= KeyF1 && k <= KeyF25:
+		return fmt.Sprint("F", int(k-KeyF1+1))
+	case k >= KeyKP0 && k <= KeyKP9:
+		return fmt.Sprint("KP", int(k-KeyKP0))
+	}
+	if name, ok := keyNames[k]; ok {
+		return name
+	}
+	return fmt.Sprint("Key", int(k))
+}
+
+// String names the button as bindings do, e.g. "MouseLeft" or "Mouse4".
+func (b MouseButton) String() string {
+	if name, ok := mouseNames[b]; ok {
+		return name
+	}
+	return fmt.Sprint("Mouse", int(b)+1)
+}
@@ -1,68 +1,203 @@
 package breakout
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"strconv"
+	"io/fs"
 	"strings"
-	"github.com/jakecoffman/learnopengl/breakout/eng"
+
 	"github.com/go-gl/mathgl/mgl32"
 )
 
 type Level struct {
-	Bricks []*Object
-	block, solid *eng.Texture2D
+	Bricks   []*Object
+	textures LevelTextures
+	file     string
+	// the format the level was read in, which Save keeps to where it can,
+	// and whether it was imported from Tiled, so is saved to a copy
+	format   int
+	imported bool
+
+	// Name, background, ball speed and paddle size come from the level file.
+	// Zero values leave the game's own. The background is drawn with the
+	// texture loaded for its path.
+	Name           string
+	BackgroundFile string
+	BallSpeed      float32
+	PaddleSize     mgl32.Vec2
+	// PaddleStart, if set, is where the paddle's center starts, in tiles from
+	// the top left of the level. Tiles can be fractions and beyond the grid.
+	PaddleStart *mgl32.Vec2
+	Spawners    []Spawner
+
+	// Tiles are rows of keys into Palette, or "." or "0" for no brick.
+	Palette map[string]BrickType
+	Tiles   [][]string
+
+	// palette entries resolved by key, and the size the level is laid out in
+	sprites             map[string]string
+	drops               map[string]*DropTable
+	lvlWidth, lvlHeight int
 }
 
-func NewLevel(block, solid *eng.Texture2D) *Level {
+// LevelTextures loads the texture for a path named in a level file, for the
+// renderer to draw the sprite of that name with. It may be nil, e.g. in a
+// headless simulation, and then nothing is loaded.
+type LevelTextures func(path string) error
+
+// NewLevel creates an empty level. Bricks without a texture of their own are
+// drawn with BlockSprite, or SolidBlockSprite if they are solid.
+func NewLevel(textures LevelTextures) *Level {
 	return &Level{
-		Bricks: []*Object{},
-		block: block,
-		solid: solid,
+		Bricks:   []*Object{},
+		textures: textures,
 	}
 }
 
-func (l *Level) Load(file string, lvlWidth, lvlHeight int) {
-	l.Bricks = l.Bricks[:0]
-
-	f, err := os.Open(file)
+// LoadLevels loads every level file, .txt, .json or .tmx, in dir of fsys, in natural
+// order so that 2.txt comes before 10.txt.
+func LoadLevels(fsys fs.FS, dir string, textures LevelTextures, lvlWidth, lvlHeight int) ([]*Level, error) {
+	files, err := levelFiles(fsys, dir)
 	if err != nil {
-		panic(err)
-	}
-	defer f.Close()
-
-	var tileData [][]int
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		parts := strings.Split(scanner.Text(), " ")
-		var row []int
-		for _, part := range parts {
-			i, err := strconv.Atoi(part)
-			if err != nil {
-				panic(fmt.Errorf("failed to parse level: %s", err.Error()))
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no levels in %s", dir)
+	}
+
+	levels := make([]*Level, 0, len(files))
+	for _, file := range files {
+		level := NewLevel(textures)
+		if err := level.Load(fsys, file, lvlWidth, lvlHeight); err != nil {
+			return nil, err
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// naturalLess orders strings with runs of digits compared as numbers.
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := digits(a), digits(b)
+		if da > 0 && db > 0 {
+			na := strings.TrimLeft(a[:da], "0")
+			nb := strings.TrimLeft(b[:db], "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
 			}
-			row = append(row, i)
+			a, b = a[da:], b[db:]
+			continue
 		}
-		tileData = append(tileData, row)
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// digits is the length of the run of ASCII digits s starts with.
+func digits(s string) int {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	return n
+}
+
+// Load reads a level from file in fsys, or the copy the editor saved of it,
+// and lays it out to fill lvlWidth by lvlHeight. The level is left as it was
+// if the file fails to load. A file that fails validation is a *LevelError.
+func (l *Level) Load(fsys fs.FS, file string, lvlWidth, lvlHeight int) error {
+	source, data, err := readLevel(fsys, file)
+	if err != nil {
+		return err
+	}
+	if diagnostics := ValidateLevel(source, data); len(diagnostics) > 0 {
+		return &LevelError{File: source, Diagnostics: diagnostics}
 	}
-	if err := scanner.Err(); err != nil {
-		panic(fmt.Errorf("failed to scan file: %s", err))
+	parsed, err := parseLevel(source, data)
+	if err != nil {
+		return err
 	}
-	if len(tileData) > 0 {
-		l.init(tileData, lvlWidth, lvlHeight)
+	parsed.Bricks = l.Bricks
+	parsed.textures = l.textures
+	if err := parsed.resolve(); err != nil {
+		return fmt.Errorf("failed to load level %s: %w", file, err)
 	}
-	return
+
+	*l = *parsed
+	l.file = file
+	l.format = levelFormat(data)
+	l.imported = source != file || l.format == formatTMX || l.format == formatTiledJSON
+	l.lvlWidth, l.lvlHeight = lvlWidth, lvlHeight
+	l.Reset()
+	return nil
 }
 
-func (l *Level) Draw(renderer *eng.SpriteRenderer) {
-	for _, tile := range l.Bricks {
-		if !tile.Destroyed {
-			tile.Draw(renderer, nil, 0)
+// resolve loads the textures and drop tables the palette and background name.
+func (l *Level) resolve() error {
+	l.sprites = map[string]string{}
+	l.drops = map[string]*DropTable{}
+	for key, brick := range l.Palette {
+		l.sprites[key] = BlockSprite
+		if brick.Solid {
+			l.sprites[key] = SolidBlockSprite
 		}
+		if brick.Texture != "" {
+			if l.textures != nil {
+				if err := l.textures(brick.Texture); err != nil {
+					return err
+				}
+			}
+			l.sprites[key] = brick.Texture
+		}
+		if brick.Drops != nil {
+			drops := &DropTable{}
+			for name, chance := range brick.Drops {
+				typ, ok := powerUpByName(name)
+				if !ok {
+					return fmt.Errorf("brick %q drops unknown power-up %q", key, name)
+				}
+				drops[typ] = chance
+			}
+			l.drops[key] = drops
+		}
+	}
+	if l.BackgroundFile != "" && l.textures != nil {
+		if err := l.textures(l.BackgroundFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset rebuilds the bricks as they were loaded.
+func (l *Level) Reset() {
+	l.Bricks = l.Bricks[:0]
+	if len(l.Tiles) > 0 && len(l.Tiles[0]) > 0 {
+		l.init(l.lvlWidth, l.lvlHeight)
 	}
 }
 
+// File is the path the level was loaded from.
+func (l *Level) File() string {
+	return l.file
+}
+
+// Size is the size the level is laid out in.
+func (l *Level) Size() (width, height int) {
+	return l.lvlWidth, l.lvlHeight
+}
+
+// Sprite is the sprite bricks of a palette key are drawn with.
+func (l *Level) Sprite(key string) string {
+	return l.sprites[key]
+}
+
 func (l *Level) IsCompleted() bool {
 	for _, tile := range l.Bricks {
 		if !tile.IsSolid && !tile.Destroyed {
@@ -72,44 +207,58 @@ func (l *Level) IsCompleted() bool {
 	return true
 }
 
-func (l *Level) init(tileData [][]int, lvlWidth, lvlHeight int) {
-	height := len(tileData)
-	width := len(tileData[0])
+// TileAt is the column and row of the tile under pos, reporting whether pos
+// is on the grid at all.
+func (l *Level) TileAt(pos mgl32.Vec2) (column, row int, ok bool) {
+	if len(l.Tiles) == 0 || len(l.Tiles[0]) == 0 {
+		return 0, 0, false
+	}
+	unitWidth := l.lvlWidth / len(l.Tiles[0])
+	unitHeight := l.lvlHeight / len(l.Tiles)
+	if unitWidth <= 0 || unitHeight <= 0 || pos.X() < 0 || pos.Y() < 0 {
+		return 0, 0, false
+	}
+	column, row = int(pos.X())/unitWidth, int(pos.Y())/unitHeight
+	return column, row, column < len(l.Tiles[0]) && row < len(l.Tiles)
+}
+
+// TileSize is the size of a tile as the level is laid out.
+func (l *Level) TileSize() mgl32.Vec2 {
+	if len(l.Tiles) == 0 || len(l.Tiles[0]) == 0 {
+		return mgl32.Vec2{}
+	}
+	return Vec2(l.lvlWidth/len(l.Tiles[0]), l.lvlHeight/len(l.Tiles))
+}
+
+// tilePosition is where pos, in tiles, is in the play field.
+func (l *Level) tilePosition(pos mgl32.Vec2) mgl32.Vec2 {
+	size := l.TileSize()
+	return mgl32.Vec2{pos.X() * size.X(), pos.Y() * size.Y()}
+}
+
+func (l *Level) init(lvlWidth, lvlHeight int) {
+	height := len(l.Tiles)
+	width := len(l.Tiles[0])
 	unitWidth := lvlWidth / width
 	unitHeight := lvlHeight / height
 
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			if tileData[y][x] == 1 {
-				pos := Vec2(unitWidth*x, unitHeight*y)
-				size := Vec2(unitWidth, unitHeight)
-				obj := NewGameObject(pos, size, l.solid)
-				obj.Color = mgl32.Vec3{.8, .8, .7}
-				obj.IsSolid = true
-				l.Bricks = append(l.Bricks, obj)
-			} else if tileData[y][x] > 1 {
-				color := mgl32.Vec3{1, 1, 1}
-				switch tileData[y][x] {
-				case 2:
-					color = mgl32.Vec3{.2, .6, 1}
-				case 3:
-					color = mgl32.Vec3{0, .7, 0}
-				case 4:
-					color = mgl32.Vec3{.8, .8, .4}
-				case 5:
-					color = mgl32.Vec3{1, .5, 0}
-				}
-
-				pos := Vec2(unitWidth*x, unitHeight*y)
-				size := Vec2(unitWidth, unitHeight)
-				obj := NewGameObject(pos, size, l.block)
-				obj.Color = color
-				l.Bricks = append(l.Bricks, obj)
+	for y, row := range l.Tiles {
+		for x, key := range row {
+			brick, ok := l.Palette[key]
+			if !ok || emptyTile(key) {
+				continue
 			}
+			pos := Vec2(unitWidth*x, unitHeight*y)
+			size := Vec2(unitWidth, unitHeight)
+			obj := NewGameObject(pos, size, l.sprites[key])
+			obj.Color = brick.Color
+			obj.IsSolid = brick.Solid
+			obj.Points = brick.Score
+			obj.HitPoints = brick.HitPoints
+			obj.Drops = l.drops[key]
+			l.Bricks = append(l.Bricks, obj)
 		}
 	}
-
-	return
 }
 
 func Vec2(x, y int) mgl32.Vec2 {
@@ -0,0 +1,402 @@
+package breakout
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// levelVersion is the newest version of the JSON level format.
+const levelVersion = 1
+
+// levelFile is the JSON level format:
+//
+//	{
+//	  "version": 1,
+//	  "name": "Stripes",
+//	  "background": "textures/background.jpg",
+//	  "ballSpeed": 400,
+//	  "paddleSize": [120, 20],
+//	  "paddleStart": [4.5, 16],
+//	  "spawners": [{"powerUp": "sticky", "position": [2.5, 3], "interval": 15}],
+//	  "palette": {
+//	    "#": {"solid": true, "color": [0.8, 0.8, 0.7]},
+//	    "r": {"color": [1, 0.2, 0.2], "hitPoints": 3, "score": 50, "drops": {"speed": 10}}
+//	  },
+//	  "bricks": [
+//	    "# r r r #",
+//	    "# . . . #"
+//	  ]
+//	}
+//
+// Bricks are rows of palette keys separated by spaces, with "." or "0" for
+// no brick. Positions are in tiles, see Level.PaddleStart, and spawners drop
+// a power-up every interval seconds, 10 if left out. Everything but version,
+// palette and bricks may be left out.
+type levelFile struct {
+	Version     int                  `json:"version"`
+	Name        string               `json:"name,omitempty"`
+	Background  string               `json:"background,omitempty"`
+	BallSpeed   float32              `json:"ballSpeed,omitempty"`
+	PaddleSize  *mgl32.Vec2          `json:"paddleSize,omitempty"`
+	PaddleStart *mgl32.Vec2          `json:"paddleStart,omitempty"`
+	Spawners    []spawnerFile        `json:"spawners,omitempty"`
+	Palette     map[string]BrickType `json:"palette"`
+	Bricks      []string             `json:"bricks"`
+}
+
+// spawnerFile is a Spawner in the JSON level format.
+type spawnerFile struct {
+	PowerUp  string     `json:"powerUp"`
+	Position mgl32.Vec2 `json:"position"`
+	Interval float32    `json:"interval,omitempty"`
+}
+
+// BrickType is an entry in a level's palette.
+type BrickType struct {
+	// Solid bricks can't be destroyed.
+	Solid bool       `json:"solid,omitempty"`
+	Color mgl32.Vec3 `json:"color"`
+	// Texture is a path in the game's assets, or empty for the default block.
+	Texture string `json:"texture,omitempty"`
+	// HitPoints is how many hits the brick takes to destroy.
+	HitPoints int `json:"hitPoints"`
+	// Score is what destroying the brick is worth, before any combo.
+	Score int `json:"score"`
+	// Drops maps power-up names to a one in n chance of dropping them, and
+	// nil drops the default power-ups.
+	Drops map[string]int `json:"drops"`
+}
+
+// UnmarshalJSON fills in defaults for the fields a palette entry leaves out.
+func (b *BrickType) UnmarshalJSON(data []byte) error {
+	type plain BrickType
+	brick := plain{Color: DefaultGameObjectColor, HitPoints: 1, Score: 10}
+	if err := json.Unmarshal(data, &brick); err != nil {
+		return err
+	}
+	*b = BrickType(brick)
+	return nil
+}
+
+// emptyTile reports whether a tile key means there is no brick.
+func emptyTile(key string) bool {
+	return key == "." || key == "0"
+}
+
+// legacyPalette is what the numbers in the original level format mean.
+var legacyPalette = map[string]BrickType{
+	"1": {Solid: true, Color: mgl32.Vec3{.8, .8, .7}, HitPoints: 1},
+	"2": {Color: mgl32.Vec3{.2, .6, 1}, HitPoints: 1, Score: 10},
+	"3": {Color: mgl32.Vec3{0, .7, 0}, HitPoints: 1, Score: 20},
+	"4": {Color: mgl32.Vec3{.8, .8, .4}, HitPoints: 1, Score: 30},
+	"5": {Color: mgl32.Vec3{1, .5, 0}, HitPoints: 1, Score: 50},
+}
+
+// level file formats, told apart by what is in the file rather than its name
+const (
+	formatLegacy = iota
+	formatJSON
+	formatTMX
+	formatTiledJSON
+)
+
+// levelFormat tells which format a level file is in: TMX starts with a tag,
+// JSON with a brace and Tiled's JSON says it is a map.
+func levelFormat(data []byte) int {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	switch {
+	case len(trimmed) == 0:
+		return formatLegacy
+	case trimmed[0] == '<':
+		return formatTMX
+	case trimmed[0] == '{' && isTiledJSON(data):
+		return formatTiledJSON
+	case trimmed[0] == '{':
+		return formatJSON
+	}
+	return formatLegacy
+}
+
+// parseLevel reads a level in any format. The level has no bricks or
+// textures yet. Files should be checked with ValidateLevel first, parsing only
+// fails on what it can't read.
+func parseLevel(file string, data []byte) (*Level, error) {
+	switch format := levelFormat(data); format {
+	case formatJSON:
+		return parseJSONLevel(file, data)
+	case formatTMX, formatTiledJSON:
+		var err error
+		l := parseTiledLevel(data, format == formatTMX, func(offset int, format string, args ...interface{}) {
+			if err == nil {
+				err = fmt.Errorf("failed to parse level %s: %s", file, fmt.Sprintf(format, args...))
+			}
+		})
+		return l, err
+	}
+	return parseLegacyLevel(file, data)
+}
+
+// parseLegacyLevel reads the original format: rows of whitespace separated
+// integers where 1 is solid, 2 to 5 are colored bricks and 0 is empty. The
+// palette has every brick, used or not, so they can all be painted.
+func parseLegacyLevel(file string, data []byte) (*Level, error) {
+	palette := map[string]BrickType{}
+	for key, brick := range legacyPalette {
+		palette[key] = brick
+	}
+	var tiles [][]string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+		var row []string
+		for _, part := range parts {
+			i, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse level %s line %d: %w", file, line, err)
+			}
+			key := strconv.Itoa(i)
+			if brick, ok := legacyPalette[key]; ok {
+				palette[key] = brick
+			} else {
+				key = "0"
+			}
+			row = append(row, key)
+		}
+		tiles = append(tiles, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan level %s: %w", file, err)
+	}
+
+	return &Level{Palette: palette, Tiles: tiles}, nil
+}
+
+func parseJSONLevel(file string, data []byte) (*Level, error) {
+	var lf levelFile
+	if err := json.Unmarshal(data, &lf); err != nil {
+		return nil, fmt.Errorf("failed to parse level %s: %w", file, err)
+	}
+	if lf.Version < 1 || lf.Version > levelVersion {
+		return nil, fmt.Errorf("failed to parse level %s: unsupported version %d", file, lf.Version)
+	}
+
+	var tiles [][]string
+	for _, line := range lf.Bricks {
+		if row := strings.Fields(line); len(row) > 0 {
+			tiles = append(tiles, row)
+		}
+	}
+
+	l := &Level{
+		Name:           lf.Name,
+		BackgroundFile: lf.Background,
+		BallSpeed:      lf.BallSpeed,
+		Palette:        lf.Palette,
+		Tiles:          tiles,
+	}
+	if lf.PaddleSize != nil {
+		l.PaddleSize = *lf.PaddleSize
+	}
+	l.PaddleStart = lf.PaddleStart
+	for _, sf := range lf.Spawners {
+		typ, _ := powerUpByName(sf.PowerUp)
+		spawner := Spawner{PowerUp: typ, Position: sf.Position, Interval: sf.Interval}
+		if spawner.Interval == 0 {
+			spawner.Interval = defaultSpawnInterval
+		}
+		l.Spawners = append(l.Spawners, spawner)
+	}
+	return l, nil
+}
+
+// Encode writes the level in the JSON format, whichever format it was loaded
+// from, laid out like the example on levelFile with a line per field,
+// spawner, palette entry and row of bricks.
+func (l *Level) Encode() ([]byte, error) {
+	e := &levelEncoder{}
+	e.field("version", levelVersion)
+	if l.Name != "" {
+		e.field("name", l.Name)
+	}
+	if l.BackgroundFile != "" {
+		e.field("background", l.BackgroundFile)
+	}
+	if l.BallSpeed != 0 {
+		e.field("ballSpeed", l.BallSpeed)
+	}
+	if l.PaddleSize != (mgl32.Vec2{}) {
+		e.field("paddleSize", l.PaddleSize)
+	}
+	if l.PaddleStart != nil {
+		e.field("paddleStart", l.PaddleStart)
+	}
+	if len(l.Spawners) > 0 {
+		e.lines("spawners", '[', ']', len(l.Spawners), func(i int) {
+			spawner := l.Spawners[i]
+			e.value(spawnerFile{
+				PowerUp:  powerUps[spawner.PowerUp].name,
+				Position: spawner.Position,
+				Interval: spawner.Interval,
+			})
+		})
+	}
+	keys := make([]string, 0, len(l.Palette))
+	for key := range l.Palette {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	e.lines("palette", '{', '}', len(keys), func(i int) {
+		e.value(keys[i])
+		e.buf.WriteString(": ")
+		e.value(l.Palette[keys[i]])
+	})
+	e.lines("bricks", '[', ']', len(l.Tiles), func(i int) {
+		e.value(strings.Join(l.Tiles[i], " "))
+	})
+	return e.bytes()
+}
+
+// levelEncoder writes a JSON object a field to a line, with the fields that
+// are lists a line per item.
+type levelEncoder struct {
+	buf    bytes.Buffer
+	fields int
+	err    error
+}
+
+// key starts the next field.
+func (e *levelEncoder) key(name string) {
+	if e.fields == 0 {
+		e.buf.WriteString("{\n  ")
+	} else {
+		e.buf.WriteString(",\n  ")
+	}
+	e.fields++
+	e.value(name)
+	e.buf.WriteString(": ")
+}
+
+// value writes v on one line.
+func (e *levelEncoder) value(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil && e.err == nil {
+		e.err = err
+	}
+	e.buf.Write(data)
+}
+
+func (e *levelEncoder) field(name string, v interface{}) {
+	e.key(name)
+	e.value(v)
+}
+
+// lines writes a field holding n items between open and close, each written
+// by item on a line of its own.
+func (e *levelEncoder) lines(name string, open, close byte, n int, item func(i int)) {
+	e.key(name)
+	e.buf.WriteByte(open)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		e.buf.WriteString("\n    ")
+		item(i)
+	}
+	if n > 0 {
+		e.buf.WriteString("\n  ")
+	}
+	e.buf.WriteByte(close)
+}
+
+func (e *levelEncoder) bytes() ([]byte, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	e.buf.WriteString("\n}\n")
+	return e.buf.Bytes(), nil
+}
+
+// encodeLegacy writes the level as a grid of brick numbers, or reports that
+// it has bricks the legacy format has no number for.
+func (l *Level) encodeLegacy() ([]byte, bool) {
+	var buf bytes.Buffer
+	for _, row := range l.Tiles {
+		for x, key := range row {
+			if emptyTile(key) {
+				key = "0"
+			} else if _, ok := legacyPalette[key]; !ok {
+				return nil, false
+			}
+			if x > 0 {
+				buf.WriteByte(' ')
+			}
+			buf.WriteString(key)
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), true
+}
+
+// editedSuffix is added to the file of a level imported from Tiled for the
+// copy the editor saves of it, e.g. 6.tmx.edited.json, so the map is left for
+// Tiled. Listing levels skips these and loading reads them in place of the
+// map.
+const editedSuffix = ".edited.json"
+
+// readLevel reads file of fsys, or the copy the editor saved of it, and says
+// which it read.
+func readLevel(fsys fs.FS, file string) (string, []byte, error) {
+	data, err := fs.ReadFile(fsys, file+editedSuffix)
+	if err == nil {
+		return file + editedSuffix, data, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", nil, err
+	}
+	data, err = fs.ReadFile(fsys, file)
+	return file, data, err
+}
+
+// SaveFile is the file the editor saves the level to: the file it was
+// loaded from, or for a level imported from Tiled the copy beside it that
+// loading reads in its place.
+func (l *Level) SaveFile() string {
+	if l.imported {
+		return l.file + editedSuffix
+	}
+	return l.file
+}
+
+// Save writes the level to path in the format it was loaded from where it
+// can: legacy grids stay grids and everything else is written as JSON, which
+// Load reads whatever the file's extension. A level that wouldn't load back
+// isn't written and is a *LevelError.
+func (l *Level) Save(path string) error {
+	var data []byte
+	var ok bool
+	if l.format == formatLegacy {
+		data, ok = l.encodeLegacy()
+	}
+	if !ok {
+		var err error
+		if data, err = l.Encode(); err != nil {
+			return err
+		}
+	}
+	if diagnostics := ValidateLevel(path, data); len(diagnostics) > 0 {
+		return &LevelError{File: path, Diagnostics: diagnostics}
+	}
+	return writeFile(path, data)
+}
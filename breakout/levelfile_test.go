@@ -0,0 +1,197 @@
+package breakout
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+const stripes = `{
+  "version": 1,
+  "name": "Stripes \"2\"",
+  "background": "textures/background.jpg",
+  "ballSpeed": 400,
+  "paddleSize": [120,20],
+  "paddleStart": [4.5,16],
+  "spawners": [
+    {"powerUp":"sticky","position":[2.5,3],"interval":15},
+    {"powerUp":"chaos","position":[1,1],"interval":10}
+  ],
+  "palette": {
+    "#": {"solid":true,"color":[0.8,0.8,0.7],"hitPoints":1,"score":10,"drops":null},
+    "r": {"color":[1,0.2,0.2],"texture":"textures/red.png","hitPoints":3,"score":50,"drops":{"speed":10}}
+  },
+  "bricks": [
+    "# r r r #",
+    "# . . . #"
+  ]
+}
+`
+
+func TestEncodeLevel(t *testing.T) {
+	l, err := parseLevel("stripes.json", []byte(stripes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := l.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != stripes {
+		t.Errorf("encoded\n%s\nwant\n%s", data, stripes)
+	}
+	if diagnostics := ValidateLevel("stripes.json", data); len(diagnostics) > 0 {
+		t.Errorf("encoded level doesn't validate: %v", diagnostics)
+	}
+}
+
+func TestEncodeLevelRoundTrip(t *testing.T) {
+	tests := []struct {
+		name, file, data string
+	}{
+		{"minimal", "min.json", `{"version": 1, "palette": {"x": {}}, "bricks": ["x"]}`},
+		{"legacy", "1.txt", "1 2 3\n0 4 5\n"},
+		{"empty palette", "empty.json", `{"version": 1, "palette": {}, "bricks": []}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			want, err := parseLevel(test.file, []byte(test.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := want.Encode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseLevel("encoded.json", data)
+			if err != nil {
+				t.Fatalf("%v in\n%s", err, data)
+			}
+			// maps print sorted, so equal levels print the same
+			if fmt.Sprint(got.Palette, got.Tiles) != fmt.Sprint(want.Palette, want.Tiles) {
+				t.Errorf("decoded %v %v, want %v %v", got.Palette, got.Tiles, want.Palette, want.Tiles)
+			}
+		})
+	}
+}
+
+func TestSaveLevel(t *testing.T) {
+	fsys := fstest.MapFS{
+		"levels/1.txt":  {Data: []byte("1 2\n0 3\n")},
+		"levels/2.json": {Data: []byte(stripes)},
+		"levels/6.tmx":  {Data: mustReadFile(t, "levels/6.tmx")},
+	}
+	tests := []struct {
+		file, saveFile string
+		legacy         bool
+	}{
+		{"levels/1.txt", "levels/1.txt", true},
+		{"levels/2.json", "levels/2.json", false},
+		{"levels/6.tmx", "levels/6.tmx.edited.json", false},
+	}
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			l := NewLevel(nil)
+			if err := l.Load(fsys, test.file, testWidth, testHeight/2); err != nil {
+				t.Fatal(err)
+			}
+			if got := l.SaveFile(); got != test.saveFile {
+				t.Fatalf("save file %q, want %q", got, test.saveFile)
+			}
+			// the editor's eraser is written as the format's own empty tile
+			l.Tiles[0][0] = eraser
+			path := filepath.Join(t.TempDir(), filepath.Base(test.saveFile))
+			if err := l.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			data := mustReadFile(t, path)
+			if legacy := levelFormat(data) == formatLegacy; legacy != test.legacy {
+				t.Errorf("saved legacy %v, want %v:\n%s", legacy, test.legacy, data)
+			}
+			saved := NewLevel(nil)
+			if err := saved.Load(os.DirFS(filepath.Dir(path)), filepath.Base(path), testWidth, testHeight/2); err != nil {
+				t.Fatal(err)
+			}
+			if !emptyTile(saved.Tiles[0][0]) || fmt.Sprint(saved.Tiles[1:]) != fmt.Sprint(l.Tiles[1:]) {
+				t.Errorf("saved tiles %v, want %v", saved.Tiles, l.Tiles)
+			}
+		})
+	}
+}
+
+func TestSaveAndReloadLevels(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string][]byte{
+		"1.txt":  []byte("1 2\n0 3\n"),
+		"2.json": []byte(stripes),
+		"6.tmx":  mustReadFile(t, "levels/6.tmx"),
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func() []*Level {
+		t.Helper()
+		levels, err := LoadLevels(os.DirFS(dir), ".", nil, testWidth, testHeight/2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return levels
+	}
+	save := func(levels []*Level) {
+		t.Helper()
+		for _, l := range levels {
+			l.Tiles[0][0] = eraser
+			if err := l.Save(filepath.Join(dir, l.SaveFile())); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	levels := load()
+	// saved twice, the second time from the levels as reloaded
+	for i := 0; i < 2; i++ {
+		save(levels)
+		levels = load()
+		var files []string
+		for _, l := range levels {
+			files = append(files, l.File())
+			if !emptyTile(l.Tiles[0][0]) {
+				t.Errorf("%s tiles %v, want the saved edit", l.File(), l.Tiles)
+			}
+		}
+		if got := fmt.Sprint(files); got != "[1.txt 2.json 6.tmx]" {
+			t.Errorf("reloaded %v, want the same three levels", got)
+		}
+	}
+	if data := mustReadFile(t, filepath.Join(dir, "6.tmx")); string(data) != string(mustReadFile(t, "levels/6.tmx")) {
+		t.Error("the Tiled map was written over")
+	}
+}
+
+func TestSaveLegacyLevel(t *testing.T) {
+	l, err := parseLevel("1.txt", []byte("1 2 3\n0 4 5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Tiles[1][2] = eraser
+	path := filepath.Join(t.TempDir(), "1.txt")
+	if err := l.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(mustReadFile(t, path)), "1 2 3\n0 4 0\n"; got != want {
+		t.Errorf("saved %q, want %q", got, want)
+	}
+}
+
+func mustReadFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -2,8 +2,8 @@ package breakout
 
 import (
 	"fmt"
+
 	"github.com/go-gl/mathgl/mgl32"
-	"github.com/jakecoffman/learnopengl/breakout/eng"
 )
 
 type Object struct {
@@ -12,8 +12,16 @@ type Object struct {
 	Rotation                 float64
 
 	IsSolid, Destroyed bool
-
-	Sprite *eng.Texture2D
+	// Points is what destroying the object is worth, before any combo.
+	Points int
+	// HitPoints is how many more hits a brick takes before it is destroyed.
+	HitPoints int
+	// Drops, if set, replaces the default chances of dropping power-ups.
+	Drops *DropTable
+
+	// Sprite names the texture the object is drawn with, for the renderer
+	// to look up.
+	Sprite string
 }
 
 func (o Object) String() string {
@@ -24,14 +32,20 @@ var (
 	DefaultGameObjectColor = mgl32.Vec3{1, 1, 1}
 )
 
-func NewGameObject(pos, size mgl32.Vec2, sprite *eng.Texture2D) *Object {
-	return &Object{pos, size, mgl32.Vec2{0, 0}, DefaultGameObjectColor, 0, false, false, sprite,}
-}
+// The sprites objects are drawn with, besides power-ups and the textures
+// levels name.
+const (
+	BlockSprite      = "block"
+	SolidBlockSprite = "block_solid"
+	PaddleSprite     = "paddle"
+	BallSprite       = "face"
+)
 
-func (o *Object) Draw(renderer *eng.SpriteRenderer, last *mgl32.Vec2, alpha float32) {
-	pos := o.Position
-	if last != nil {
-		pos = pos.Mul(alpha).Add(last.Mul(1.0-alpha))
+func NewGameObject(pos, size mgl32.Vec2, sprite string) *Object {
+	return &Object{
+		Position: pos,
+		Size:     size,
+		Color:    DefaultGameObjectColor,
+		Sprite:   sprite,
 	}
-	renderer.DrawSprite(o.Sprite, pos, o.Size, o.Rotation, o.Color)
 }
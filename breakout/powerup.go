@@ -0,0 +1,248 @@
+package breakout
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// PowerUpType identifies the effect a power-up has once collected.
+type PowerUpType int
+
+const (
+	PowerUpSpeed PowerUpType = iota
+	PowerUpSticky
+	PowerUpPassThrough
+	PowerUpPadSizeIncrease
+	PowerUpConfuse
+	PowerUpChaos
+
+	powerUpCount
+)
+
+var (
+	powerUpSize     = mgl32.Vec2{60, 20}
+	powerUpVelocity = mgl32.Vec2{0, 150}
+	speedIncrease   = float32(1.2)
+	padSizeIncrease = float32(50)
+	stickyColor     = mgl32.Vec3{1, .5, 1}
+	passColor       = mgl32.Vec3{1, .5, .5}
+)
+
+// powerUps describes each type, indexed by PowerUpType. Levels refer to
+// types by name. A destroyed brick drops each type with a one in chance
+// probability, e.g. 75 drops it once in 75 bricks on average. Types that
+// stack apply again for every one collected; the rest refresh the running
+// duration.
+var powerUps = [powerUpCount]struct {
+	name     string
+	texture  string
+	color    mgl32.Vec3
+	duration float32
+	chance   int
+	stacks   bool
+}{
+	PowerUpSpeed:           {"speed", "powerup_speed", mgl32.Vec3{.5, .5, 1}, 10, 75, true},
+	PowerUpSticky:          {"sticky", "powerup_sticky", mgl32.Vec3{1, .5, 1}, 20, 75, false},
+	PowerUpPassThrough:     {"pass-through", "powerup_passthrough", mgl32.Vec3{.5, 1, .5}, 10, 75, false},
+	PowerUpPadSizeIncrease: {"pad-size-increase", "powerup_increase", mgl32.Vec3{1, .6, .4}, 10, 75, true},
+	PowerUpConfuse:         {"confuse", "powerup_confuse", mgl32.Vec3{1, .3, .3}, 15, 15, false},
+	PowerUpChaos:           {"chaos", "powerup_chaos", mgl32.Vec3{.9, .25, .25}, 15, 15, false},
+}
+
+// DropTable is the one in n chance of a brick dropping each PowerUpType when
+// destroyed. Zero never drops that type.
+type DropTable [powerUpCount]int
+
+// defaultDrops is what bricks without a drop table of their own drop.
+var defaultDrops = func() (drops DropTable) {
+	for typ, info := range powerUps {
+		drops[typ] = info.chance
+	}
+	return drops
+}()
+
+// PowerUpSprites are the sprites power-ups are drawn with, for the renderer
+// to load.
+func PowerUpSprites() []string {
+	sprites := make([]string, len(powerUps))
+	for typ, info := range powerUps {
+		sprites[typ] = info.texture
+	}
+	return sprites
+}
+
+func powerUpByName(name string) (PowerUpType, bool) {
+	for typ, info := range powerUps {
+		if info.name == name {
+			return PowerUpType(typ), true
+		}
+	}
+	return 0, false
+}
+
+// Spawner drops a power-up from the same place every Interval seconds while
+// its level is played.
+type Spawner struct {
+	PowerUp PowerUpType
+	// Position is in tiles, like Level.PaddleStart.
+	Position mgl32.Vec2
+	Interval float32
+}
+
+// PowerUp falls from a destroyed brick until the paddle collects it, then
+// stays Activated for Duration seconds.
+type PowerUp struct {
+	*Object
+
+	Type      PowerUpType
+	Duration  float32
+	Activated bool
+}
+
+// NewPowerUp creates a power-up of typ falling from pos.
+func NewPowerUp(typ PowerUpType, pos mgl32.Vec2) *PowerUp {
+	p := &PowerUp{
+		Type:     typ,
+		Duration: powerUps[typ].duration,
+	}
+	p.Object = NewGameObject(pos, powerUpSize, powerUps[typ].texture)
+	p.Color = powerUps[typ].color
+	p.Velocity = powerUpVelocity
+	return p
+}
+
+// spawnPowerUps rolls for every type at the position of a destroyed brick.
+func (s *Simulation) spawnPowerUps(brick *Object) {
+	drops := &defaultDrops
+	if brick.Drops != nil {
+		drops = brick.Drops
+	}
+	for typ, chance := range drops {
+		if chance > 0 && s.rand.Intn(chance) == 0 {
+			s.PowerUps = append(s.PowerUps, NewPowerUp(PowerUpType(typ), brick.Position))
+		}
+	}
+}
+
+// updateSpawners drops power-ups from the level's spawners when they are due.
+func (s *Simulation) updateSpawners(dt float32) {
+	level := s.Levels[s.Level]
+	if len(s.spawnTimers) != len(level.Spawners) {
+		s.spawnTimers = make([]float32, len(level.Spawners))
+	}
+	for i, spawner := range level.Spawners {
+		s.spawnTimers[i] += dt
+		if s.spawnTimers[i] < spawner.Interval {
+			continue
+		}
+		s.spawnTimers[i] -= spawner.Interval
+		pos := level.tilePosition(spawner.Position).Sub(powerUpSize.Mul(.5))
+		s.PowerUps = append(s.PowerUps, NewPowerUp(spawner.PowerUp, pos))
+	}
+}
+
+// updatePowerUps moves falling power-ups, collects the ones that touch the
+// paddle and expires the active ones.
+func (s *Simulation) updatePowerUps(dt float32) {
+	for _, p := range s.PowerUps {
+		if !p.Destroyed {
+			p.Position = p.Position.Add(p.Velocity.Mul(dt))
+			if checkCollision(s.Player, p.Object) {
+				p.Destroyed = true
+				s.activatePowerUp(p)
+			} else if p.Position.Y() >= float32(s.Height) {
+				p.Destroyed = true
+			}
+		}
+		if p.Activated {
+			p.Duration -= dt
+			if p.Duration <= 0 {
+				p.Activated = false
+				s.deactivatePowerUp(p.Type)
+			}
+		}
+	}
+
+	live := s.PowerUps[:0]
+	for _, p := range s.PowerUps {
+		if !p.Destroyed || p.Activated {
+			live = append(live, p)
+		}
+	}
+	for i := len(live); i < len(s.PowerUps); i++ {
+		s.PowerUps[i] = nil
+	}
+	s.PowerUps = live
+}
+
+func (s *Simulation) activatePowerUp(p *PowerUp) {
+	if !powerUps[p.Type].stacks {
+		for _, other := range s.PowerUps {
+			if other.Activated && other.Type == p.Type {
+				other.Duration = powerUps[p.Type].duration
+				return
+			}
+		}
+	}
+	p.Activated = true
+
+	switch p.Type {
+	case PowerUpSpeed:
+		s.Ball.Velocity = s.Ball.Velocity.Mul(speedIncrease)
+	case PowerUpSticky:
+		s.Ball.Sticky = true
+		s.Player.Color = stickyColor
+	case PowerUpPassThrough:
+		s.Ball.PassThrough = true
+		s.Ball.Color = passColor
+	case PowerUpPadSizeIncrease:
+		s.Player.Size = mgl32.Vec2{s.Player.Size.X() + padSizeIncrease, s.Player.Size.Y()}
+		s.movePaddle(0)
+	case PowerUpConfuse:
+		s.endPowerUp(PowerUpChaos)
+		s.Confuse = true
+	case PowerUpChaos:
+		s.endPowerUp(PowerUpConfuse)
+		s.Chaos = true
+	}
+}
+
+// endPowerUp ends the active power-ups of typ early, e.g. for one that
+// replaces it.
+func (s *Simulation) endPowerUp(typ PowerUpType) {
+	for _, p := range s.PowerUps {
+		if p.Activated && p.Type == typ {
+			p.Activated = false
+			s.deactivatePowerUp(typ)
+		}
+	}
+}
+
+func (s *Simulation) deactivatePowerUp(typ PowerUpType) {
+	switch typ {
+	case PowerUpSpeed:
+		s.Ball.Velocity = s.Ball.Velocity.Mul(1 / speedIncrease)
+	case PowerUpSticky:
+		s.Ball.Sticky = false
+		s.Player.Color = DefaultGameObjectColor
+	case PowerUpPassThrough:
+		s.Ball.PassThrough = false
+		s.Ball.Color = DefaultGameObjectColor
+	case PowerUpPadSizeIncrease:
+		s.Player.Size = mgl32.Vec2{s.Player.Size.X() - padSizeIncrease, s.Player.Size.Y()}
+		s.movePaddle(0)
+	case PowerUpConfuse:
+		s.Confuse = false
+	case PowerUpChaos:
+		s.Chaos = false
+	}
+}
+
+// clearPowerUps drops every falling power-up and ends every active effect.
+func (s *Simulation) clearPowerUps() {
+	for _, p := range s.PowerUps {
+		if p.Activated {
+			s.deactivatePowerUp(p.Type)
+		}
+	}
+	s.PowerUps = nil
+}
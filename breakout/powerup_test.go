@@ -0,0 +1,111 @@
+package breakout
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/jakecoffman/learnopengl/breakout/input"
+)
+
+// collect has the paddle collect a power-up of typ.
+func collect(s *Simulation, typ PowerUpType) *PowerUp {
+	p := NewPowerUp(typ, s.Player.Position)
+	s.PowerUps = append(s.PowerUps, p)
+	s.updatePowerUps(0)
+	return p
+}
+
+// expire runs the active power-ups out.
+func expire(s *Simulation) {
+	for _, p := range s.PowerUps {
+		if p.Activated {
+			p.Duration = 0
+		}
+	}
+	s.updatePowerUps(testStep)
+}
+
+func TestConfuseAndChaosReplaceEachOther(t *testing.T) {
+	tests := []struct {
+		name          string
+		first, second PowerUpType
+	}{
+		{"chaos replaces confuse", PowerUpConfuse, PowerUpChaos},
+		{"confuse replaces chaos", PowerUpChaos, PowerUpConfuse},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestSimulation(t, "2")
+			press(s, input.KeyEnter)
+			first := collect(s, test.first)
+			second := collect(s, test.second)
+
+			if first.Activated || !second.Activated {
+				t.Fatalf("first active %v, second active %v, want only the second", first.Activated, second.Activated)
+			}
+			want := test.second == PowerUpConfuse
+			if s.Confuse != want || s.Chaos != !want {
+				t.Fatalf("confuse %v, chaos %v, want only the second's effect", s.Confuse, s.Chaos)
+			}
+			expire(s)
+			if s.Confuse || s.Chaos {
+				t.Errorf("confuse %v, chaos %v after expiring, want neither", s.Confuse, s.Chaos)
+			}
+		})
+	}
+}
+
+func TestPadSizeStaysInside(t *testing.T) {
+	s := newTestSimulation(t, "2")
+	press(s, input.KeyEnter)
+	s.Player.Position[0] = testWidth - s.Player.Size.X()
+	offset := s.Ball.Position.X() - s.Player.Position.X()
+
+	collect(s, PowerUpPadSizeIncrease)
+	collect(s, PowerUpPadSizeIncrease)
+	if want := playerSize.X() + 2*padSizeIncrease; s.Player.Size.X() != want {
+		t.Fatalf("paddle %v wide, want %v", s.Player.Size.X(), want)
+	}
+	if right := s.Player.Position.X() + s.Player.Size.X(); right > testWidth {
+		t.Errorf("paddle reaches %v, past the right wall", right)
+	}
+	if got := s.Ball.Position.X() - s.Player.Position.X(); abs(got-offset) > 1e-3 {
+		t.Errorf("stuck ball %v from the paddle, want %v", got, offset)
+	}
+
+	expire(s)
+	if s.Player.Size != playerSize {
+		t.Errorf("paddle %v after expiring, want %v", s.Player.Size, playerSize)
+	}
+	if x := s.Player.Position.X(); x < 0 || x+s.Player.Size.X() > testWidth {
+		t.Errorf("paddle at %v, outside the walls", x)
+	}
+}
+
+func TestPowerUpRefreshes(t *testing.T) {
+	s := newTestSimulation(t, "2")
+	press(s, input.KeyEnter)
+	first := collect(s, PowerUpSticky)
+	first.Duration = 1
+	second := collect(s, PowerUpSticky)
+	if second.Activated || first.Duration != powerUps[PowerUpSticky].duration {
+		t.Errorf("second active %v, first has %v left, want the first refreshed", second.Activated, first.Duration)
+	}
+	if !s.Ball.Sticky || s.Player.Color != stickyColor {
+		t.Error("sticky not applied")
+	}
+}
+
+func TestSpawnPowerUps(t *testing.T) {
+	s := newTestSimulation(t, "2")
+	s.Seed(1)
+	brick := NewGameObject(mgl32.Vec2{10, 20}, mgl32.Vec2{50, 20}, BlockSprite)
+	brick.Drops = &DropTable{PowerUpChaos: 1}
+	s.spawnPowerUps(brick)
+	if len(s.PowerUps) != 1 || s.PowerUps[0].Type != PowerUpChaos || s.PowerUps[0].Position != brick.Position {
+		t.Fatalf("spawned %v, want one chaos power-up at the brick", s.PowerUps)
+	}
+	if s.PowerUps[0].Sprite != "powerup_chaos" {
+		t.Errorf("sprite %q, want powerup_chaos", s.PowerUps[0].Sprite)
+	}
+}
@@ -0,0 +1,93 @@
+package breakout
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Progress is what carries over from one run of the game to the next.
+type Progress struct {
+	// Unlocked is the file of the furthest level reached, as Level.File has
+	// it, so progress survives levels being added or renumbered.
+	Unlocked  string `json:"unlocked"`
+	HighScore int    `json:"highScore"`
+}
+
+// DefaultProgressFile is where progress is kept in the user's config
+// directory, e.g. ~/.config/breakout/progress.json on Linux.
+func DefaultProgressFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "breakout", "progress.json"), nil
+}
+
+// LoadProgress reads progress saved to path. A file that doesn't exist yet is
+// no progress at all.
+func LoadProgress(path string) (Progress, error) {
+	var p Progress
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return p, err
+	}
+	err = json.Unmarshal(data, &p)
+	return p, err
+}
+
+// Save writes p to path, creating its directory.
+func (p Progress) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+// writeFile replaces the file at path with data, creating its directory. The
+// data is written beside it first so a crash can't leave it half written.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Progress returns the progress made in the simulation so far.
+func (s *Simulation) Progress() Progress {
+	p := Progress{HighScore: s.HighScore}
+	if s.Unlocked < len(s.Levels) {
+		p.Unlocked = s.Levels[s.Unlocked].File()
+	}
+	return p
+}
+
+// SetProgress restores saved progress, selecting the furthest level reached
+// and laying it out afresh. Call it once Levels are loaded: a level that no
+// longer exists starts from the first one.
+func (s *Simulation) SetProgress(p Progress) {
+	s.Unlocked = 0
+	for i, level := range s.Levels {
+		if p.Unlocked != "" && level.File() == p.Unlocked {
+			s.Unlocked = i
+		}
+	}
+	if p.HighScore > s.HighScore {
+		s.HighScore = p.HighScore
+	}
+	s.Level = s.Unlocked
+	if s.Level < len(s.Levels) {
+		s.resetLevel()
+	}
+}
@@ -0,0 +1,94 @@
+package breakout
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestProgressByFile(t *testing.T) {
+	s := newTestSimulation(t, "2", "2", "2")
+	s.SetProgress(Progress{Unlocked: "levels/2.txt", HighScore: 100})
+	if s.Unlocked != 1 || s.Level != 1 || s.HighScore != 100 {
+		t.Fatalf("unlocked %d, level %d, high score %d, want 1, 1 and 100", s.Unlocked, s.Level, s.HighScore)
+	}
+
+	// a level added in front moves the unlocked one along
+	s.Levels = append([]*Level{NewLevel(nil)}, s.Levels...)
+	s.Levels[0].file = "levels/0.txt"
+	s.SetProgress(Progress{Unlocked: "levels/2.txt"})
+	if s.Unlocked != 2 {
+		t.Errorf("unlocked %d after a level was added in front, want 2", s.Unlocked)
+	}
+	if got := s.Progress().Unlocked; got != "levels/2.txt" {
+		t.Errorf("progress unlocked %q, want levels/2.txt", got)
+	}
+
+	s.SetProgress(Progress{Unlocked: "levels/gone.txt"})
+	if s.Unlocked != 0 || s.Level != 0 {
+		t.Errorf("unlocked %d, level %d for a level that's gone, want the first", s.Unlocked, s.Level)
+	}
+	if s.HighScore != 100 {
+		t.Errorf("high score %d, want it kept at 100", s.HighScore)
+	}
+}
+
+func TestProgressWithoutLevels(t *testing.T) {
+	s := NewSimulation(testWidth, testHeight)
+	s.SetProgress(Progress{Unlocked: "levels/2.txt", HighScore: 5})
+	if s.Unlocked != 0 || s.Level != 0 || s.HighScore != 5 {
+		t.Errorf("unlocked %d, level %d, high score %d, want 0, 0 and 5", s.Unlocked, s.Level, s.HighScore)
+	}
+	if got := s.Progress(); got.Unlocked != "" {
+		t.Errorf("progress unlocked %q, want nothing", got.Unlocked)
+	}
+}
+
+func TestLoadProgress(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		file     string
+		unlocked int
+	}{
+		{"by file", `{"unlocked": "levels/3.txt", "highScore": 7}`, 2},
+		{"nothing unlocked", `{"highScore": 7}`, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, "progress.json")
+			if err := ioutil.WriteFile(path, []byte(test.file), 0644); err != nil {
+				t.Fatal(err)
+			}
+			p, err := LoadProgress(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := newTestSimulation(t, "2", "2", "2", "2")
+			s.SetProgress(p)
+			if s.Unlocked != test.unlocked || s.HighScore != 7 {
+				t.Errorf("unlocked %d, high score %d, want %d and 7", s.Unlocked, s.HighScore, test.unlocked)
+			}
+		})
+	}
+}
+
+func TestSaveProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breakout", "progress.json")
+	want := Progress{Unlocked: "levels/2.txt", HighScore: 42}
+	if err := want.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("loaded %+v, want %+v", got, want)
+	}
+
+	missing, err := LoadProgress(filepath.Join(t.TempDir(), "none.json"))
+	if err != nil || missing != (Progress{}) {
+		t.Errorf("missing file loaded %+v, %v, want no progress", missing, err)
+	}
+}
@@ -0,0 +1,333 @@
+package breakout
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/jakecoffman/learnopengl/breakout/input"
+)
+
+// EventKind is which input an Event changes.
+type EventKind uint8
+
+const (
+	KeyEvent EventKind = iota
+	ButtonEvent
+	JoystickButtonEvent
+	JoystickAxisEvent
+	CursorEvent
+)
+
+// Event is an input changing before the update numbered Tick.
+type Event struct {
+	Tick uint64
+	Kind EventKind
+	// Code is the key, mouse button, joystick button or joystick axis.
+	Code int
+	// Pressed is whether a key or button went down rather than up.
+	Pressed bool
+	// Value is where a joystick axis moved to, from -1 to 1.
+	Value float32
+	// Cursor is where the mouse moved to, in play field coordinates.
+	Cursor mgl32.Vec2
+}
+
+// Replay is everything a session depends on besides the levels: the random
+// seed, the timestep, where it started, how the input was bound and every
+// input event. Played back into a fresh simulation with the same levels it
+// comes out exactly the same.
+type Replay struct {
+	Seed int64
+	Step float32
+	// Level and Unlocked are the progress the session started with.
+	Level, Unlocked int
+	// Bindings and Controls are the session's, which the events mean what
+	// they did with.
+	Bindings input.Bindings
+	Controls Controls
+	// Ticks is how many updates the session ran for.
+	Ticks  uint64
+	Events []Event
+}
+
+// replayMagic starts replay files, followed by the format's version.
+const (
+	replayMagic   = "breakout replay"
+	replayVersion = 2
+)
+
+// MarshalBinary encodes the replay compactly: the header with the bindings
+// as config files name them, then each event as the ticks since the one
+// before, its kind and what changed. Keys and buttons have their state in
+// the low bit.
+func (r *Replay) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	var scratch [binary.MaxVarintLen64]byte
+	uvarint := func(v uint64) {
+		buf.Write(scratch[:binary.PutUvarint(scratch[:], v)])
+	}
+	varint := func(v int64) {
+		buf.Write(scratch[:binary.PutVarint(scratch[:], v)])
+	}
+	float := func(v float32) {
+		uvarint(uint64(math.Float32bits(v)))
+	}
+	str := func(v string) {
+		uvarint(uint64(len(v)))
+		buf.WriteString(v)
+	}
+
+	buf.WriteString(replayMagic)
+	buf.WriteByte(replayVersion)
+	varint(r.Seed)
+	float(r.Step)
+	uvarint(uint64(r.Level))
+	uvarint(uint64(r.Unlocked))
+	uvarint(r.Ticks)
+	actions := make([]string, 0, len(r.Bindings))
+	for action := range r.Bindings {
+		actions = append(actions, string(action))
+	}
+	sort.Strings(actions)
+	uvarint(uint64(len(actions)))
+	for _, action := range actions {
+		str(action)
+		bindings := r.Bindings[input.Action(action)]
+		uvarint(uint64(len(bindings)))
+		for _, b := range bindings {
+			str(b.String())
+		}
+	}
+	float(r.Controls.MouseSensitivity)
+	float(r.Controls.Deadzone)
+	uvarint(uint64(len(r.Events)))
+	var tick uint64
+	for _, e := range r.Events {
+		if e.Tick < tick {
+			return nil, fmt.Errorf("replay events out of order at tick %d", e.Tick)
+		}
+		uvarint(e.Tick - tick)
+		tick = e.Tick
+		buf.WriteByte(byte(e.Kind))
+		switch e.Kind {
+		case KeyEvent, ButtonEvent, JoystickButtonEvent:
+			code := int64(e.Code) << 1
+			if e.Pressed {
+				code |= 1
+			}
+			varint(code)
+		case JoystickAxisEvent:
+			uvarint(uint64(e.Code))
+			float(e.Value)
+		case CursorEvent:
+			float(e.Cursor.X())
+			float(e.Cursor.Y())
+		default:
+			return nil, fmt.Errorf("replay event of unknown kind %d at tick %d", e.Kind, e.Tick)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a replay encoded by MarshalBinary.
+func (r *Replay) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, []byte(replayMagic)) {
+		return errors.New("not a replay file")
+	}
+	buf := bytes.NewReader(data[len(replayMagic):])
+	version, err := buf.ReadByte()
+	if err != nil {
+		return io.ErrUnexpectedEOF
+	}
+	if version != replayVersion {
+		return fmt.Errorf("replay is version %d, want %d", version, replayVersion)
+	}
+
+	// the first error sticks and the rest of the reads return zero
+	uvarint := func() uint64 {
+		if err != nil {
+			return 0
+		}
+		var v uint64
+		v, err = binary.ReadUvarint(buf)
+		return v
+	}
+	varint := func() int64 {
+		if err != nil {
+			return 0
+		}
+		var v int64
+		v, err = binary.ReadVarint(buf)
+		return v
+	}
+	float := func() float32 {
+		return math.Float32frombits(uint32(uvarint()))
+	}
+	str := func() string {
+		n := uvarint()
+		if err == nil && n > uint64(buf.Len()) {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return ""
+		}
+		v := make([]byte, n)
+		buf.Read(v)
+		return string(v)
+	}
+
+	*r = Replay{
+		Seed:     varint(),
+		Step:     float(),
+		Level:    int(uvarint()),
+		Unlocked: int(uvarint()),
+		Ticks:    uvarint(),
+		Bindings: input.Bindings{},
+	}
+	actions := uvarint()
+	for i := uint64(0); i < actions && err == nil; i++ {
+		action := input.Action(str())
+		count := uvarint()
+		if err == nil && count > uint64(buf.Len()) {
+			return io.ErrUnexpectedEOF
+		}
+		bindings := []input.Binding{}
+		for j := uint64(0); j < count && err == nil; j++ {
+			b, parseErr := input.ParseBinding(str())
+			if err == nil && parseErr != nil {
+				return fmt.Errorf("replay binding for %s: %w", action, parseErr)
+			}
+			bindings = append(bindings, b)
+		}
+		r.Bindings[action] = bindings
+	}
+	r.Controls = Controls{MouseSensitivity: float(), Deadzone: float()}
+	count := uvarint()
+	if err == nil && count > uint64(buf.Len()) {
+		// every event takes at least two bytes, so the count is corrupt
+		return io.ErrUnexpectedEOF
+	}
+	var tick uint64
+	for i := uint64(0); i < count && err == nil; i++ {
+		tick += uvarint()
+		e := Event{Tick: tick}
+		if err == nil {
+			var kind byte
+			kind, err = buf.ReadByte()
+			e.Kind = EventKind(kind)
+		}
+		switch e.Kind {
+		case KeyEvent, ButtonEvent, JoystickButtonEvent:
+			code := varint()
+			e.Code, e.Pressed = int(code>>1), code&1 == 1
+		case JoystickAxisEvent:
+			e.Code = int(uvarint())
+			e.Value = float()
+		case CursorEvent:
+			e.Cursor = mgl32.Vec2{float(), float()}
+		default:
+			return fmt.Errorf("replay event of unknown kind %d at tick %d", e.Kind, e.Tick)
+		}
+		r.Events = append(r.Events, e)
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// LoadReplay reads a replay saved to path.
+func LoadReplay(path string) (*Replay, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Replay{}
+	if err := r.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Save writes r to path, creating its directory.
+func (r *Replay) Save(path string) error {
+	data, err := r.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+// Record seeds the simulation and starts recording its input events into the
+// replay it returns, stepped by step seconds. Call it before the first update
+// and set the replay's Ticks from Tick when the session ends.
+func (s *Simulation) Record(seed int64, step float32) *Replay {
+	s.Seed(seed)
+	bindings := input.Bindings{}
+	for action, b := range s.Input.Bindings {
+		bindings[action] = append([]input.Binding(nil), b...)
+	}
+	s.Recording = &Replay{
+		Seed: seed, Step: step, Level: s.Level, Unlocked: s.Unlocked,
+		Bindings: bindings,
+		Controls: Controls{MouseSensitivity: s.MouseSensitivity, Deadzone: s.Input.Deadzone},
+	}
+	return s.Recording
+}
+
+// Play starts feeding r's events back into the simulation in place of the
+// keyboard, mouse and joystick, from its first update. Levels must be loaded
+// and the same as when r was recorded. r's bindings and controls replace the
+// simulation's and stay after it ends.
+func (s *Simulation) Play(r *Replay) {
+	s.Seed(r.Seed)
+	s.Input.Bindings = DefaultBindings()
+	s.Bind(r.Bindings)
+	s.SetControls(r.Controls)
+	// the levels are the same, so the indices still hold
+	s.Unlocked = 0
+	if r.Unlocked > 0 && r.Unlocked < len(s.Levels) {
+		s.Unlocked = r.Unlocked
+	}
+	s.Level = s.Unlocked
+	if r.Level >= 0 && r.Level <= s.Unlocked {
+		s.Level = r.Level
+	}
+	s.resetLevel()
+	s.resetPlayer()
+	s.Tick = 0
+	s.replay, s.replayed = r, 0
+}
+
+// Replaying reports whether a replay is being played and has events left.
+func (s *Simulation) Replaying() bool {
+	return s.replay != nil && s.replayed < len(s.replay.Events)
+}
+
+// PlayOut plays r to its end as fast as it goes, e.g. to check the levels
+// it leaves behind.
+func (s *Simulation) PlayOut(r *Replay) {
+	s.Play(r)
+	for s.Tick < r.Ticks {
+		s.Update(r.Step)
+	}
+}
+
+// replayEvents applies the replayed events due before this update.
+func (s *Simulation) replayEvents() {
+	if s.replay == nil {
+		return
+	}
+	events := s.replay.Events
+	for s.replayed < len(events) && events[s.replayed].Tick <= s.Tick {
+		s.apply(events[s.replayed])
+		s.replayed++
+	}
+}
@@ -0,0 +1,205 @@
+package breakout
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/jakecoffman/learnopengl/breakout/input"
+)
+
+var update = flag.Bool("update", false, "record the replays in testdata again and rewrite what they should leave behind")
+
+func TestReplayRoundTrip(t *testing.T) {
+	r := &Replay{Seed: -7, Step: testStep, Level: 2, Unlocked: 3, Ticks: 500, Bindings: input.Bindings{
+		ActionLeft:   {input.BindKey(input.KeyJ), input.BindJoystickButton(13)},
+		ActionLaunch: {input.BindMouseButton(input.MouseButtonLeft)},
+		ActionPause:  {},
+	}, Controls: Controls{MouseSensitivity: -1, Deadzone: .3}, Events: []Event{
+		{Tick: 0, Kind: KeyEvent, Code: int(input.KeyEnter), Pressed: true},
+		{Tick: 0, Kind: CursorEvent, Cursor: mgl32.Vec2{12.5, -3}},
+		{Tick: 4, Kind: ButtonEvent, Code: int(input.MouseButtonRight), Pressed: true},
+		{Tick: 4, Kind: JoystickButtonEvent, Code: 7},
+		{Tick: 300, Kind: JoystickAxisEvent, Code: 1, Value: -.75},
+		{Tick: 301, Kind: KeyEvent, Code: int(input.KeyEnter)},
+	}}
+	data, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Replay
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(*r) {
+		t.Errorf("decoded\n%v\nwant\n%v", got, *r)
+	}
+
+	for n := len(replayMagic) + 1; n < len(data); n++ {
+		if err := got.UnmarshalBinary(data[:n]); err == nil {
+			t.Errorf("decoded %d of %d bytes without an error", n, len(data))
+		}
+	}
+}
+
+func TestRecordAllInput(t *testing.T) {
+	s := newTestSimulation(t, "2 2")
+	r := s.Record(1, testStep)
+	s.SetKey(input.KeyEnter, true)
+	s.SetCursor(mgl32.Vec2{10, 20})
+	s.Update(testStep)
+	s.SetButton(input.MouseButtonLeft, true)
+	// polled every update, but only changes are recorded
+	for i := 0; i < 3; i++ {
+		s.SetJoystickAxis(0, .5)
+		s.SetJoystickButton(0, true)
+		s.SetJoystickButton(1, false)
+		s.Update(testStep)
+	}
+
+	want := []Event{
+		{Tick: 0, Kind: KeyEvent, Code: int(input.KeyEnter), Pressed: true},
+		{Tick: 0, Kind: CursorEvent, Cursor: mgl32.Vec2{10, 20}},
+		{Tick: 1, Kind: ButtonEvent, Code: int(input.MouseButtonLeft), Pressed: true},
+		{Tick: 1, Kind: JoystickAxisEvent, Code: 0, Value: .5},
+		{Tick: 1, Kind: JoystickButtonEvent, Code: 0, Pressed: true},
+	}
+	if fmt.Sprint(r.Events) != fmt.Sprint(want) {
+		t.Errorf("recorded\n%v\nwant\n%v", r.Events, want)
+	}
+}
+
+// hold sets a key as a callback would, only when it changes.
+func hold(s *Simulation, key input.Key, down bool) {
+	if s.Input.KeyDown(key) != down {
+		s.SetKey(key, down)
+	}
+}
+
+// chase is how far the ball is across from the middle of the paddle.
+func chase(s *Simulation) float32 {
+	return s.Ball.Center().X() - (s.Player.Position.X() + s.Player.Size.X()/2)
+}
+
+// replaySessions are scripted sessions on the shipped levels, each played
+// with a different device, that the replays in testdata were recorded from.
+// Each is seeded differently, bound and controlled other than by default so
+// the replay has to carry them, and called before every update.
+var replaySessions = []struct {
+	name     string
+	seed     int64
+	bindings input.Bindings
+	controls Controls
+	play     func(s *Simulation)
+}{
+	{"keys", 1, input.Bindings{
+		ActionLeft:  {input.BindKey(input.KeyJ)},
+		ActionRight: {input.BindKey(input.KeyL)},
+	}, Controls{}, func(s *Simulation) {
+		hold(s, input.KeyEnter, s.Tick == 0)
+		hold(s, input.KeySpace, s.Ball.Stuck && s.Tick%2 == 1)
+		dx := chase(s)
+		hold(s, input.KeyJ, dx < -10)
+		hold(s, input.KeyL, dx > 10)
+	}},
+	{"mouse", 2, nil, Controls{MouseSensitivity: .1}, func(s *Simulation) {
+		hold(s, input.KeyEnter, s.Tick == 0)
+		if down := s.Ball.Stuck && s.Tick%2 == 1; s.Input.ButtonDown(input.MouseButtonLeft) != down {
+			s.SetButton(input.MouseButtonLeft, down)
+		}
+		if cursor := (mgl32.Vec2{s.Ball.Center().X(), s.Cursor.Y()}); cursor != s.Cursor {
+			s.SetCursor(cursor)
+		}
+	}},
+	{"joystick", 3, nil, Controls{Deadzone: .3}, func(s *Simulation) {
+		s.SetJoystickButton(7, s.Tick == 0)
+		s.SetJoystickButton(0, s.Ball.Stuck && s.Tick%2 == 1)
+		// a quarter at a time, as a stick held still is
+		s.SetJoystickAxis(0, mgl32.Clamp(float32(int(chase(s)/25))/4, -1, 1))
+	}},
+}
+
+// newReplaySimulation is a headless simulation of the shipped levels.
+func newReplaySimulation(t *testing.T) *Simulation {
+	t.Helper()
+	s := NewSimulation(testWidth, testHeight)
+	var err error
+	s.Levels, err = LoadLevels(Assets, "levels", nil, testWidth, testHeight/2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetProgress(Progress{})
+	return s
+}
+
+// replayResult describes what a session left behind: the score and every
+// level's bricks, each destroyed (x), solid (#) or its hit points.
+func replayResult(s *Simulation) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "tick %d, level %s, score %d, lives %d\n", s.Tick, s.Levels[s.Level].File(), s.Score, s.Lives)
+	for _, level := range s.Levels {
+		fmt.Fprintf(&b, "%s: ", level.File())
+		for _, brick := range level.Bricks {
+			switch {
+			case brick.Destroyed:
+				b.WriteByte('x')
+			case brick.IsSolid:
+				b.WriteByte('#')
+			default:
+				fmt.Fprint(&b, brick.HitPoints)
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// recordSession plays a scripted session for a minute with its bindings and
+// controls and saves its replay and what it left behind to testdata.
+func recordSession(t *testing.T, name string, seed int64, bindings input.Bindings, controls Controls, play func(s *Simulation)) {
+	t.Helper()
+	s := newReplaySimulation(t)
+	s.Bind(bindings)
+	s.SetControls(controls)
+	r := s.Record(seed, testStep)
+	for s.Tick < 60*60 {
+		play(s)
+		s.Update(testStep)
+	}
+	r.Ticks = s.Tick
+	if err := r.Save(filepath.Join("testdata", name+".replay")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join("testdata", name+".golden"), []byte(replayResult(s)), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplayFixtures(t *testing.T) {
+	for _, session := range replaySessions {
+		name := session.name
+		if *update {
+			recordSession(t, name, session.seed, session.bindings, session.controls, session.play)
+		}
+		t.Run(name, func(t *testing.T) {
+			r, err := LoadReplay(filepath.Join("testdata", name+".replay"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := ioutil.ReadFile(filepath.Join("testdata", name+".golden"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := newReplaySimulation(t)
+			s.Seed(r.Seed)
+			s.PlayOut(r)
+			if got := replayResult(s); got != string(want) {
+				t.Errorf("replay left\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
@@ -1,5 +1,6 @@
 #version 330 core
 in vec2 TexCoords;
+in vec3 VertexColor;
 out vec4 color;
 
 uniform sampler2D image;
@@ -7,5 +8,5 @@ uniform vec3 spriteColor;
 
 void main()
 {
-    color = vec4(spriteColor, 1.0) * texture(image, TexCoords);
+    color = vec4(spriteColor * VertexColor, 1.0) * texture(image, TexCoords);
 }
@@ -1,7 +1,9 @@
 #version 330 core
 layout (location = 0) in vec4 vertex; // <vec2 position, vec2 texCoords>
+layout (location = 1) in vec3 vertexColor;
 
 out vec2 TexCoords;
+out vec3 VertexColor;
 
 uniform mat4 model;
 uniform mat4 projection;
@@ -9,5 +11,6 @@ uniform mat4 projection;
 void main()
 {
     TexCoords = vertex.zw;
+    VertexColor = vertexColor;
     gl_Position = projection * model * vec4(vertex.xy, 0.0, 1.0);
 }
@@ -1,17 +1,17 @@
 #version 330 core
 layout (location = 0) in vec4 vertex; // <vec2 position, vec2 texCoords>
+layout (location = 1) in vec2 offset; // per instance from here on
+layout (location = 2) in float size;
+layout (location = 3) in vec4 color;
 
 out vec2 TexCoords;
 out vec4 ParticleColor;
 
 uniform mat4 projection;
-uniform vec2 offset;
-uniform vec4 color;
 
 void main()
 {
-    float scale = 10.0f;
     TexCoords = vertex.zw;
     ParticleColor = color;
-    gl_Position = projection * vec4((vertex.xy * scale) + offset, 0.0, 1.0);
-}
\ No newline at end of file
+    gl_Position = projection * vec4((vertex.xy * size) + offset, 0.0, 1.0);
+}
@@ -0,0 +1,43 @@
+#version 330 core
+in vec2 TexCoords;
+out vec4 color;
+
+uniform sampler2D scene;
+uniform vec2 offsets[9];
+uniform int edgeKernel[9];
+uniform float blurKernel[9];
+
+uniform bool chaos;
+uniform bool confuse;
+uniform bool shake;
+uniform bool blur;
+uniform bool invert;
+
+void main()
+{
+    color = vec4(0.0);
+    vec3 samples[9];
+    // sample from texture offsets if using a convolution matrix
+    if (chaos || shake || blur) {
+        for (int i = 0; i < 9; i++) {
+            samples[i] = vec3(texture(scene, TexCoords.st + offsets[i]));
+        }
+    }
+
+    if (chaos) {
+        for (int i = 0; i < 9; i++) {
+            color += vec4(samples[i] * edgeKernel[i], 0.0);
+        }
+        color.a = 1.0;
+    } else if (shake || blur) {
+        for (int i = 0; i < 9; i++) {
+            color += vec4(samples[i] * blurKernel[i], 0.0);
+        }
+        color.a = 1.0;
+    } else {
+        color = texture(scene, TexCoords);
+    }
+    if (confuse || invert) {
+        color = vec4(1.0 - color.rgb, 1.0);
+    }
+}
@@ -0,0 +1,367 @@
+package breakout
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/jakecoffman/learnopengl/breakout/input"
+)
+
+// Simulation is the gameplay half of breakout: paddle, ball, levels and
+// collisions. It never touches GL or the window so it can be stepped
+// headless, e.g. from tests or a CI job without a GPU.
+type Simulation struct {
+	state State
+	// Input is what the player does, read as actions once per update.
+	Input *input.Input
+	// Cursor is where the mouse is in play field coordinates.
+	Cursor mgl32.Vec2
+	// MouseSensitivity scales how fast the paddle follows the cursor, as
+	// SetControls sets it. Negative leaves the mouse out of it.
+	MouseSensitivity float32
+	// mouseFollow is set when the mouse moves, for the paddle to follow the
+	// cursor until keys or a stick move it instead
+	mouseFollow bool
+	// cursorSet is set once the cursor has had a position
+	cursorSet bool
+
+	Width, Height int
+
+	// Quit is set when the player asks to leave the game from the menu.
+	Quit bool
+
+	Levels []*Level
+	Level  int
+	// Unlocked is the furthest level reached; the menu offers levels up to it.
+	Unlocked int
+
+	Player *Object
+	Ball   *Ball
+
+	Lives     int
+	Score     int
+	HighScore int
+	// Combo counts bricks destroyed since the ball last touched the paddle
+	// and multiplies the points of the next one.
+	Combo int
+
+	PowerUps []*PowerUp
+	// seconds since each of the level's spawners last dropped a power-up
+	spawnTimers []float32
+	// Confuse and Chaos are screen effects for the renderer to apply, as is
+	// shaking the screen while ShakeTime is above zero. Only one of Confuse
+	// and Chaos is on at a time; collecting either ends the other.
+	Confuse, Chaos bool
+	ShakeTime      float32
+
+	// BrickDestroyed, if set, is called for every brick the ball destroys.
+	BrickDestroyed func(brick *Object)
+
+	// SaveLevel, if set, lets the menu open the level editor and saves the
+	// level being edited, to its SaveFile.
+	SaveLevel func(level *Level) error
+	Editor    *Editor
+	// playTest is set while playing a level from the editor, which ending
+	// goes back to.
+	playTest bool
+
+	// Tick counts updates.
+	Tick uint64
+	// Recording, if set, has every input event from SetKey and friends
+	// appended to it.
+	Recording *Replay
+	// the replay being played back and how many of its events have been
+	replay   *Replay
+	replayed int
+
+	rand *rand.Rand
+}
+
+var (
+	playerSize          = mgl32.Vec2{100, 20}
+	playerVelocity      = float32(500.0)
+	mouseVelocity       = 4 * playerVelocity
+	initialBallVelocity = Vec2(100, -350)
+	ballRadius          = float32(25)
+	initialLives        = 3
+	maxCombo            = 8
+	shakeDuration       = float32(.05)
+	damageFade          = float32(.75)
+)
+
+// NewSimulation creates a simulation for a play field of the given size.
+// Levels are appended to Levels by the caller.
+func NewSimulation(width, height int) *Simulation {
+	s := &Simulation{
+		Width:  width,
+		Height: height,
+		Lives:  initialLives,
+		Input:  input.NewInput(DefaultBindings()),
+		rand:   rand.New(rand.NewSource(1)),
+
+		MouseSensitivity: 1,
+	}
+
+	playerPos := mgl32.Vec2{float32(s.Width)/2.0 - playerSize.X()/2.0, float32(s.Height) - playerSize.Y()}
+	s.Player = NewGameObject(playerPos, playerSize, PaddleSprite)
+
+	ballPos := playerPos.Add(mgl32.Vec2{playerSize.X()/2.0 - ballRadius, -ballRadius * 2})
+	s.Ball = NewBall(ballPos, ballRadius, initialBallVelocity, BallSprite)
+
+	s.state = StateMenu
+	return s
+}
+
+// Seed reseeds the random source behind power-up drops. A simulation is
+// deterministic for a given seed and input.
+func (s *Simulation) Seed(seed int64) {
+	s.rand = rand.New(rand.NewSource(seed))
+}
+
+// SetKey records the pressed state of a key, as the window key callback does.
+func (s *Simulation) SetKey(key input.Key, pressed bool) {
+	s.feed(Event{Kind: KeyEvent, Code: int(key), Pressed: pressed})
+}
+
+// SetCursor records where the mouse is, in play field coordinates.
+func (s *Simulation) SetCursor(pos mgl32.Vec2) {
+	s.feed(Event{Kind: CursorEvent, Cursor: pos})
+}
+
+// SetButton records the pressed state of a mouse button.
+func (s *Simulation) SetButton(button input.MouseButton, pressed bool) {
+	s.feed(Event{Kind: ButtonEvent, Code: int(button), Pressed: pressed})
+}
+
+// SetJoystickButton records the pressed state of a joystick button. Only
+// changes are recorded, so it can be polled every frame.
+func (s *Simulation) SetJoystickButton(button int, pressed bool) {
+	if s.Input.JoystickButtonDown(button) != pressed {
+		s.feed(Event{Kind: JoystickButtonEvent, Code: button, Pressed: pressed})
+	}
+}
+
+// SetJoystickAxis records where a joystick axis is, from -1 to 1. Only
+// changes are recorded, so it can be polled every frame.
+func (s *Simulation) SetJoystickAxis(axis int, value float32) {
+	if s.Input.JoystickAxis(axis) != value {
+		s.feed(Event{Kind: JoystickAxisEvent, Code: axis, Value: value})
+	}
+}
+
+// feed applies an input event as it happens, recording it if the session is
+// being recorded.
+func (s *Simulation) feed(e Event) {
+	if s.Recording != nil {
+		e.Tick = s.Tick
+		s.Recording.Events = append(s.Recording.Events, e)
+	}
+	s.apply(e)
+}
+
+// apply changes the input as e says, whether it is happening or replayed.
+func (s *Simulation) apply(e Event) {
+	switch e.Kind {
+	case KeyEvent:
+		s.Input.SetKey(input.Key(e.Code), e.Pressed)
+	case ButtonEvent:
+		s.Input.SetButton(input.MouseButton(e.Code), e.Pressed)
+	case JoystickButtonEvent:
+		s.Input.SetJoystickButton(e.Code, e.Pressed)
+	case JoystickAxisEvent:
+		s.Input.SetJoystickAxis(e.Code, e.Value)
+	case CursorEvent:
+		// the first position is where the cursor was, not a move
+		if s.cursorSet && e.Cursor.X() != s.Cursor.X() {
+			s.mouseFollow = true
+		}
+		s.Cursor, s.cursorSet = e.Cursor, true
+	}
+}
+
+// Update advances the simulation by dt seconds.
+func (s *Simulation) Update(dt float32) {
+	s.replayEvents()
+	s.Input.Update()
+	s.Tick++
+	s.processInput(dt)
+	if s.state != StateActive {
+		return
+	}
+
+	if s.ShakeTime > 0 {
+		s.ShakeTime -= dt
+	}
+	s.doCollisions(dt)
+	s.updateSpawners(dt)
+	s.updatePowerUps(dt)
+	if s.Levels[s.Level].IsCompleted() {
+		s.completeLevel()
+	} else if s.Ball.Position.Y() >= float32(s.Height) {
+		s.loseBall()
+	}
+}
+
+// completeLevel moves on to the next level, or wins the game after the last.
+func (s *Simulation) completeLevel() {
+	if s.playTest {
+		s.edit()
+		return
+	}
+	if s.Level+1 >= len(s.Levels) {
+		s.state = StateWin
+		return
+	}
+	s.Level++
+	if s.Level > s.Unlocked {
+		s.Unlocked = s.Level
+	}
+	s.Combo = 0
+	s.resetLevel()
+	s.resetPlayer()
+}
+
+// loseBall costs a life and puts a new ball on the paddle, or ends the game
+// when there are no lives left.
+func (s *Simulation) loseBall() {
+	s.Lives--
+	s.Combo = 0
+	if s.Lives <= 0 && s.playTest {
+		s.edit()
+		return
+	}
+	if s.Lives <= 0 {
+		s.state = StateGameOver
+		return
+	}
+	s.resetPlayer()
+}
+
+// destroyBrick removes a brick and scores it, multiplied by the combo.
+func (s *Simulation) destroyBrick(brick *Object) {
+	brick.Destroyed = true
+	if s.Combo < maxCombo {
+		s.Combo++
+	}
+	s.Score += brick.Points * s.Combo
+	if s.Score > s.HighScore {
+		s.HighScore = s.Score
+	}
+	s.spawnPowerUps(brick)
+	if s.BrickDestroyed != nil {
+		s.BrickDestroyed(brick)
+	}
+}
+
+// hitBrick takes a hit point off a brick and destroys it once none are left,
+// reporting whether it was destroyed. Damaged bricks darken.
+func (s *Simulation) hitBrick(brick *Object) bool {
+	if brick.HitPoints > 1 {
+		brick.HitPoints--
+		brick.Color = brick.Color.Mul(damageFade)
+		return false
+	}
+	s.destroyBrick(brick)
+	return true
+}
+
+func (s *Simulation) resetLevel() {
+	s.Levels[s.Level].Reset()
+	s.spawnTimers = nil
+}
+
+// resetPlayer puts the paddle back in the middle with the ball stuck to it,
+// sized, sped up and placed as the current level asks.
+func (s *Simulation) resetPlayer() {
+	s.clearPowerUps()
+	size, velocity := playerSize, initialBallVelocity
+	position := mgl32.Vec2{float32(s.Width)/2 - size.X()/2, float32(s.Height) - size.Y()}
+	if s.Level < len(s.Levels) {
+		level := s.Levels[s.Level]
+		if level.PaddleSize != (mgl32.Vec2{}) {
+			size = level.PaddleSize
+		}
+		if level.BallSpeed > 0 {
+			velocity = velocity.Normalize().Mul(level.BallSpeed)
+		}
+		position = mgl32.Vec2{float32(s.Width)/2 - size.X()/2, float32(s.Height) - size.Y()}
+		if level.PaddleStart != nil {
+			position = level.tilePosition(*level.PaddleStart).Sub(size.Mul(.5))
+			position[0] = mgl32.Clamp(position.X(), 0, float32(s.Width)-size.X())
+		}
+	}
+	s.Player.Size = size
+	s.Player.Position = position
+	s.Ball.Reset(s.Player.Position.Add(mgl32.Vec2{size.X()/2 - ballRadius, -(ballRadius * 2)}), velocity)
+}
+
+// doCollisions moves the ball through dt seconds, sweeping it against the
+// bricks and the paddle so that a fast ball can't tunnel through them. Every
+// impact within the step is resolved in the order it happens.
+func (s *Simulation) doCollisions(dt float32) {
+	ball := s.Ball
+	if ball.Stuck {
+		return
+	}
+
+	remaining := dt
+	for i := 0; i < maxBallHits && remaining > 0; i++ {
+		displacement := ball.Velocity.Mul(remaining)
+		center := ball.Center()
+
+		var hitObject *Object
+		var toi float32 = 1
+		var normal mgl32.Vec2
+		for _, box := range s.Levels[s.Level].Bricks {
+			if box.Destroyed {
+				continue
+			}
+			if hit, t, n := sweepBall(center, ball.Radius, displacement, box); hit && (hitObject == nil || t < toi) {
+				hitObject, toi, normal = box, t, n
+			}
+		}
+		if hit, t, n := sweepBall(center, ball.Radius, displacement, s.Player); hit && (hitObject == nil || t < toi) {
+			hitObject, toi, normal = s.Player, t, n
+		}
+
+		ball.Position = ball.Position.Add(displacement.Mul(toi))
+		ball.bounceWalls(float32(s.Width))
+		remaining -= remaining * toi
+
+		if hitObject == nil {
+			return
+		}
+		if hitObject == s.Player {
+			s.Combo = 0
+			s.bouncePaddle()
+			if ball.Sticky {
+				ball.Stuck = true
+				return
+			}
+		} else {
+			if !hitObject.IsSolid {
+				if s.hitBrick(hitObject) && ball.PassThrough {
+					continue
+				}
+			} else {
+				s.ShakeTime = shakeDuration
+			}
+			ball.Velocity = reflect(ball.Velocity, normal)
+		}
+	}
+}
+
+// bouncePaddle sends the ball back up, angled by how far from the center of
+// the paddle it landed.
+func (s *Simulation) bouncePaddle() {
+	centerBoard := s.Player.Position.X() + s.Player.Size.X()/2
+	distance := (s.Ball.Position.X() + s.Ball.Radius) - centerBoard
+	percentage := distance / (s.Player.Size.X() / 2)
+
+	var strength float32 = 2.0
+	oldVelocity := s.Ball.Velocity
+	s.Ball.Velocity = mgl32.Vec2{initialBallVelocity.X() * percentage * strength, s.Ball.Velocity.Y()}
+	s.Ball.Velocity = s.Ball.Velocity.Normalize().Mul(oldVelocity.Len())
+	s.Ball.Velocity = mgl32.Vec2{s.Ball.Velocity.X(), float32(-1 * math.Abs(float64(s.Ball.Velocity.Y())))}
+}
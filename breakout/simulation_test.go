@@ -0,0 +1,271 @@
+package breakout
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/jakecoffman/learnopengl/breakout/input"
+)
+
+const (
+	testWidth  = 800
+	testHeight = 600
+	testStep   = float32(1. / 60.)
+)
+
+// newTestSimulation is a headless simulation playing the given levels, each
+// a legacy grid of tile numbers.
+func newTestSimulation(t *testing.T, levels ...string) *Simulation {
+	t.Helper()
+	fsys := fstest.MapFS{}
+	for i, level := range levels {
+		fsys["levels/"+string(rune('1'+i))+".txt"] = &fstest.MapFile{Data: []byte(level)}
+	}
+	s := NewSimulation(testWidth, testHeight)
+	var err error
+	s.Levels, err = LoadLevels(fsys, "levels", nil, testWidth, testHeight/2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetProgress(Progress{})
+	return s
+}
+
+// press taps keys together for one update.
+func press(s *Simulation, keys ...input.Key) {
+	for _, key := range keys {
+		s.SetKey(key, true)
+	}
+	s.Update(testStep)
+	for _, key := range keys {
+		s.SetKey(key, false)
+	}
+}
+
+// play updates s until done or it gives up after a minute of game time.
+func play(t *testing.T, s *Simulation, done func() bool) {
+	t.Helper()
+	for i := 0; i < 60*60; i++ {
+		if done() {
+			return
+		}
+		s.Update(testStep)
+	}
+	t.Fatal("gave up waiting")
+}
+
+func TestStartAndLaunch(t *testing.T) {
+	s := newTestSimulation(t, "0 2 0")
+	if s.State() != StateMenu {
+		t.Fatalf("state = %v, want the menu", s.State())
+	}
+	press(s, input.KeyEnter)
+	if s.State() != StateActive {
+		t.Fatalf("state = %v, want active", s.State())
+	}
+	if !s.Ball.Stuck {
+		t.Fatal("ball launched before the launch key")
+	}
+	start := s.Ball.Position
+	press(s, input.KeySpace)
+	if s.Ball.Stuck {
+		t.Fatal("ball still stuck after the launch key")
+	}
+	s.Update(testStep)
+	if s.Ball.Position.Y() >= start.Y() {
+		t.Errorf("ball moved from %v to %v, want up", start, s.Ball.Position)
+	}
+}
+
+func TestBallWinsLevel(t *testing.T) {
+	s := newTestSimulation(t, "0 2 0")
+	press(s, input.KeyEnter)
+	press(s, input.KeySpace)
+	play(t, s, func() bool { return s.State() != StateActive })
+
+	if s.State() != StateWin {
+		t.Fatalf("state = %v, want won", s.State())
+	}
+	if brick := s.Levels[0].Bricks[0]; !brick.Destroyed {
+		t.Error("brick not destroyed")
+	}
+	if s.Score == 0 || s.HighScore != s.Score {
+		t.Errorf("score %d, high score %d, want the brick's points in both", s.Score, s.HighScore)
+	}
+}
+
+func TestCompleteLevelUnlocksNext(t *testing.T) {
+	s := newTestSimulation(t, "2 2", "3 3")
+	press(s, input.KeyEnter)
+	for _, brick := range s.Levels[0].Bricks {
+		brick.Destroyed = true
+	}
+	s.Update(testStep)
+
+	if s.State() != StateActive || s.Level != 1 || s.Unlocked != 1 {
+		t.Fatalf("state %v on level %d with %d unlocked, want active on 1 with 1", s.State(), s.Level, s.Unlocked)
+	}
+	if !s.Ball.Stuck {
+		t.Error("ball not back on the paddle for the next level")
+	}
+	if got := s.Progress(); got.Unlocked != "levels/2.txt" {
+		t.Errorf("progress unlocked %q, want levels/2.txt", got.Unlocked)
+	}
+}
+
+func TestLoseBall(t *testing.T) {
+	s := newTestSimulation(t, "2")
+	press(s, input.KeyEnter)
+	for lives := initialLives - 1; lives >= 0; lives-- {
+		s.Ball.Stuck = false
+		s.Ball.Position = mgl32.Vec2{0, testHeight}
+		s.Ball.Velocity = mgl32.Vec2{0, 100}
+		s.Update(testStep)
+		if s.Lives != lives {
+			t.Fatalf("lives = %d, want %d", s.Lives, lives)
+		}
+		if lives > 0 && (s.State() != StateActive || !s.Ball.Stuck) {
+			t.Fatalf("state %v, ball stuck %v, want active with a new ball", s.State(), s.Ball.Stuck)
+		}
+	}
+	if s.State() != StateGameOver {
+		t.Fatalf("state = %v, want game over", s.State())
+	}
+	press(s, input.KeyEnter)
+	if s.State() != StateMenu {
+		t.Fatalf("state = %v, want the menu", s.State())
+	}
+}
+
+func TestPaddleStopsAtWalls(t *testing.T) {
+	s := newTestSimulation(t, "2")
+	press(s, input.KeyEnter)
+	offset := s.Ball.Position.X() - s.Player.Position.X()
+
+	s.SetKey(input.KeyRight, true)
+	play(t, s, func() bool { return s.Player.Position.X() >= testWidth-s.Player.Size.X() })
+	s.Update(testStep)
+	if x := s.Player.Position.X(); x != testWidth-s.Player.Size.X() {
+		t.Errorf("paddle at %v, want against the right wall", x)
+	}
+	s.SetKey(input.KeyRight, false)
+
+	s.SetKey(input.KeyLeft, true)
+	play(t, s, func() bool { return s.Player.Position.X() <= 0 })
+	s.Update(testStep)
+	if x := s.Player.Position.X(); x != 0 {
+		t.Errorf("paddle at %v, want against the left wall", x)
+	}
+	if got := s.Ball.Position.X() - s.Player.Position.X(); abs(got-offset) > 1e-3 {
+		t.Errorf("stuck ball %v from the paddle, want %v", got, offset)
+	}
+}
+
+func TestSolidBrickShakes(t *testing.T) {
+	s := newTestSimulation(t, "1 2")
+	press(s, input.KeyEnter)
+	solid := s.Levels[0].Bricks[0]
+	if !solid.IsSolid {
+		t.Fatal("tile 1 isn't solid")
+	}
+	// send the ball straight up into the solid brick
+	s.Ball.Stuck = false
+	s.Ball.Position = mgl32.Vec2{solid.Position.X() + 10, solid.Size.Y() + 10}
+	s.Ball.Velocity = mgl32.Vec2{0, -300}
+	play(t, s, func() bool { return s.Ball.Velocity.Y() > 0 })
+
+	if solid.Destroyed {
+		t.Error("solid brick destroyed")
+	}
+	if s.ShakeTime <= 0 {
+		t.Error("no shake from hitting a solid brick")
+	}
+	if s.Ball.Velocity.Y() <= 0 {
+		t.Errorf("ball velocity %v, want bounced down", s.Ball.Velocity)
+	}
+}
+
+func abs(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// paddleCenter is the middle of the paddle across.
+func paddleCenter(s *Simulation) float32 {
+	return s.Player.Position.X() + s.Player.Size.X()/2
+}
+
+func TestMouseFollow(t *testing.T) {
+	s := newTestSimulation(t, "2")
+	press(s, input.KeyEnter)
+	start := paddleCenter(s)
+	offset := s.Ball.Position.X() - s.Player.Position.X()
+
+	// where the cursor first is isn't a move
+	s.SetCursor(mgl32.Vec2{100, 500})
+	s.Update(testStep)
+	if got := paddleCenter(s); got != start {
+		t.Fatalf("paddle moved to %v on the first cursor position, want it left at %v", got, start)
+	}
+
+	s.SetCursor(mgl32.Vec2{200, 500})
+	s.Update(testStep)
+	if got, want := paddleCenter(s), start-mouseVelocity*testStep; abs(got-want) > 1e-3 {
+		t.Errorf("paddle at %v after one update, want %v on the way to the cursor", got, want)
+	}
+	play(t, s, func() bool { return paddleCenter(s) == 200 })
+	if got := s.Ball.Position.X() - s.Player.Position.X(); abs(got-offset) > 1e-3 {
+		t.Errorf("stuck ball %v from the paddle, want %v", got, offset)
+	}
+
+	// past the wall it stops at the wall
+	s.SetCursor(mgl32.Vec2{-50, 500})
+	play(t, s, func() bool { return s.Player.Position.X() == 0 })
+	s.Update(testStep)
+	if x := s.Player.Position.X(); x != 0 {
+		t.Errorf("paddle at %v, want against the left wall", x)
+	}
+
+	// keys take over until the mouse moves again
+	s.SetKey(input.KeyRight, true)
+	s.Update(testStep)
+	s.SetKey(input.KeyRight, false)
+	s.Update(testStep)
+	if got, want := s.Player.Position.X(), playerVelocity*testStep; abs(got-want) > 1e-3 {
+		t.Errorf("paddle at %v, want %v moved by the key and left there", got, want)
+	}
+	s.SetCursor(mgl32.Vec2{-40, 500})
+	s.Update(testStep)
+	if x := s.Player.Position.X(); x != 0 {
+		t.Errorf("paddle at %v, want following the mouse again", x)
+	}
+}
+
+func TestMouseSensitivity(t *testing.T) {
+	tests := []struct {
+		sensitivity float32
+		moved       float32
+	}{
+		{0, mouseVelocity * testStep},
+		{1, mouseVelocity * testStep},
+		{.5, mouseVelocity * testStep / 2},
+		{2, mouseVelocity * testStep * 2},
+		{-1, 0},
+		{-.5, 0},
+	}
+	for _, test := range tests {
+		s := newTestSimulation(t, "2")
+		s.SetControls(Controls{MouseSensitivity: test.sensitivity})
+		press(s, input.KeyEnter)
+		start := paddleCenter(s)
+		s.SetCursor(mgl32.Vec2{start, 500})
+		s.SetCursor(mgl32.Vec2{start + 300, 500})
+		s.Update(testStep)
+		if got := paddleCenter(s) - start; abs(got-test.moved) > 1e-3 {
+			t.Errorf("sensitivity %v: paddle moved %v, want %v", test.sensitivity, got, test.moved)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package breakout
+
+import (
+	"errors"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// State is which screen the game is on.
+type State int
+
+const (
+	StateActive State = iota
+	StateMenu
+	StateWin
+	StatePaused
+	StateGameOver
+	StateEditor
+)
+
+// State is the screen the game is on.
+func (s *Simulation) State() State {
+	return s.state
+}
+
+// PlayTesting reports whether the level being played was started from the
+// editor, which it goes back to when it ends.
+func (s *Simulation) PlayTesting() bool {
+	return s.playTest
+}
+
+func (s *Simulation) processInput(dt float32) {
+	switch s.state {
+	case StateMenu:
+		s.menuInput()
+	case StateActive:
+		s.activeInput(dt)
+	case StatePaused:
+		s.pausedInput()
+	case StateWin, StateGameOver:
+		s.endInput()
+	case StateEditor:
+		s.editorInput()
+	}
+}
+
+// menuInput picks an unlocked level and starts it. Up goes back a level and
+// down on to the next, wrapping around the unlocked ones.
+func (s *Simulation) menuInput() {
+	if s.Input.Pressed(ActionBack) {
+		s.Quit = true
+	}
+	if len(s.Levels) == 0 {
+		return
+	}
+	if s.Input.Pressed(ActionConfirm) {
+		s.start()
+		return
+	}
+	if levels := s.Unlocked + 1; levels > 1 {
+		selected := s.Level
+		if s.Input.Pressed(ActionMenuUp) {
+			selected = (selected + levels - 1) % levels
+		}
+		if s.Input.Pressed(ActionMenuDown) {
+			selected = (selected + 1) % levels
+		}
+		if selected != s.Level {
+			s.Level = selected
+			s.resetLevel()
+		}
+	}
+	if s.SaveLevel != nil && s.Input.Pressed(ActionEdit) {
+		s.edit()
+	}
+}
+
+// activeInput moves the paddle as fast as the stick is pushed, or a key
+// held, or towards the cursor once the mouse moves. Whichever moved last is
+// in control.
+func (s *Simulation) activeInput(dt float32) {
+	move := s.Input.Value(ActionRight) - s.Input.Value(ActionLeft)
+	if move != 0 {
+		s.mouseFollow = false
+	}
+	if s.mouseFollow && s.MouseSensitivity > 0 {
+		s.movePaddle(s.followCursor(dt))
+	} else {
+		s.movePaddle(move * playerVelocity * dt)
+	}
+	if s.Input.Pressed(ActionLaunch) {
+		s.Ball.Stuck = false
+	}
+	if s.playTest && s.Input.Pressed(ActionBack) {
+		s.edit()
+		return
+	}
+	if s.Input.Pressed(ActionPause) {
+		s.pause()
+	}
+}
+
+func (s *Simulation) pausedInput() {
+	if s.Input.Pressed(ActionPause) {
+		s.unpause()
+	}
+	if s.Input.Pressed(ActionQuit) {
+		s.menu()
+	}
+}
+
+// endInput handles the win and game over screens.
+func (s *Simulation) endInput() {
+	if s.Input.Pressed(ActionConfirm) {
+		s.menu()
+	}
+}
+
+// followCursor is how far the paddle moves in dt seconds towards being
+// centered on the cursor, at most mouseVelocity times MouseSensitivity a
+// second.
+func (s *Simulation) followCursor(dt float32) float32 {
+	dx := s.Cursor.X() - (s.Player.Position.X() + s.Player.Size.X()/2)
+	limit := mouseVelocity * s.MouseSensitivity * dt
+	return mgl32.Clamp(dx, -limit, limit)
+}
+
+// movePaddle moves the paddle across by dx, as far as the walls let it,
+// taking a stuck ball along.
+func (s *Simulation) movePaddle(dx float32) {
+	x := mgl32.Clamp(s.Player.Position.X()+dx, 0, float32(s.Width)-s.Player.Size.X())
+	if s.Ball.Stuck {
+		s.Ball.Position[0] += x - s.Player.Position.X()
+	}
+	s.Player.Position[0] = x
+}
+
+// editorInput paints and erases with the mouse, picks the brush, resizes
+// the grid from its right and bottom edges, undoes and redoes, saves, and
+// plays the level as it is. Going back to the menu reverts what wasn't
+// saved so the menu plays the level as it is on disk.
+func (s *Simulation) editorInput() {
+	e := s.Editor
+	paint, erase := s.Input.Down(ActionPaint), s.Input.Down(ActionErase)
+	if column, row, ok := e.Level.TileAt(s.Cursor); ok && (paint || erase) {
+		if paint {
+			e.Paint(column, row, e.Brush)
+		} else {
+			e.Paint(column, row, eraser)
+		}
+	}
+	if !paint && !erase {
+		e.EndStroke()
+	}
+
+	for i := 0; i < BrushActions; i++ {
+		if s.Input.Pressed(ActionBrush(i)) {
+			e.SelectBrush(i)
+		}
+	}
+	if s.Input.Pressed(ActionBrushPrevious) {
+		e.SelectBrush(e.brushIndex() - 1)
+	}
+	if s.Input.Pressed(ActionBrushNext) {
+		e.SelectBrush(e.brushIndex() + 1)
+	}
+
+	columns, rows := e.Size()
+	if s.Input.Pressed(ActionRemoveColumn) {
+		e.Resize(columns-1, rows)
+	}
+	if s.Input.Pressed(ActionAddColumn) {
+		e.Resize(columns+1, rows)
+	}
+	if s.Input.Pressed(ActionRemoveRow) {
+		e.Resize(columns, rows-1)
+	}
+	if s.Input.Pressed(ActionAddRow) {
+		e.Resize(columns, rows+1)
+	}
+
+	if s.Input.Pressed(ActionUndo) {
+		e.Undo()
+	}
+	if s.Input.Pressed(ActionRedo) {
+		e.Redo()
+	}
+	if s.Input.Pressed(ActionSave) {
+		s.saveLevel()
+	}
+	if s.Input.Pressed(ActionConfirm) {
+		s.start()
+		s.playTest = true
+	}
+	if s.Input.Pressed(ActionBack) {
+		if e.Revert() {
+			e.Status = "Unsaved changes reverted, undo brings them back"
+		}
+		s.menu()
+	}
+}
+
+// edit opens the editor on the selected level, keeping its history if it
+// was being edited already.
+func (s *Simulation) edit() {
+	level := s.Levels[s.Level]
+	if s.Editor == nil || s.Editor.Level != level {
+		s.Editor = NewEditor(level)
+	}
+	s.playTest = false
+	s.resetLevel()
+	s.resetPlayer()
+	s.state = StateEditor
+}
+
+// saveLevel saves the level being edited and says how that went.
+func (s *Simulation) saveLevel() {
+	err := s.SaveLevel(s.Editor.Level)
+	var levelErr *LevelError
+	switch {
+	case err == nil:
+		s.Editor.saved = copyTiles(s.Editor.Level.Tiles)
+		s.Editor.Status = "Saved to " + s.Editor.Level.SaveFile()
+	case errors.As(err, &levelErr):
+		s.Editor.Status = "Can't save: " + levelErr.Diagnostics[0].Message
+	default:
+		s.Editor.Status = "Can't save: " + err.Error()
+	}
+}
+
+// start begins the selected level from scratch.
+func (s *Simulation) start() {
+	s.resetLevel()
+	s.resetPlayer()
+	s.Lives = initialLives
+	s.Score = 0
+	s.Combo = 0
+	s.state = StateActive
+}
+
+// menu goes back to the start menu with the current level restored so it
+// can be previewed.
+func (s *Simulation) menu() {
+	s.resetLevel()
+	s.resetPlayer()
+	s.state = StateMenu
+}
+
+func (s *Simulation) pause() {
+	s.state = StatePaused
+}
+
+func (s *Simulation) unpause() {
+	s.state = StateActive
+}
@@ -0,0 +1,213 @@
+package breakout
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jakecoffman/learnopengl/breakout/input"
+)
+
+func TestMenuWithoutLevels(t *testing.T) {
+	s := NewSimulation(testWidth, testHeight)
+	press(s, input.KeyEnter)
+	press(s, input.KeyS)
+	press(s, input.KeyE)
+	if s.State() != StateMenu {
+		t.Fatalf("state = %v, want the menu", s.State())
+	}
+	press(s, input.KeyEscape)
+	if !s.Quit {
+		t.Error("back didn't quit from the menu")
+	}
+}
+
+func TestMenuSelect(t *testing.T) {
+	s := newTestSimulation(t, "2", "2", "2", "2")
+	s.SetProgress(Progress{Unlocked: "levels/3.txt"})
+	if s.Level != 2 {
+		t.Fatalf("level = %d, want the furthest unlocked", s.Level)
+	}
+
+	// up goes back through the list and down on, wrapping around only
+	// the unlocked levels
+	for _, step := range []struct {
+		key  input.Key
+		want int
+	}{
+		{input.KeyUp, 1},
+		{input.KeyW, 0},
+		{input.KeyUp, 2},
+		{input.KeyDown, 0},
+		{input.KeyS, 1},
+		{input.KeyDown, 2},
+	} {
+		press(s, step.key)
+		if s.Level != step.want {
+			t.Fatalf("after %v level = %d, want %d", step.key, s.Level, step.want)
+		}
+	}
+}
+
+func TestMenuStartIgnoresSelection(t *testing.T) {
+	s := newTestSimulation(t, "2", "2")
+	s.SetProgress(Progress{Unlocked: "levels/2.txt"})
+	s.Level = 0
+	press(s, input.KeyEnter, input.KeyDown)
+	if s.State() != StateActive || s.Level != 0 {
+		t.Fatalf("state %v on level %d, want active on the level selected when starting", s.State(), s.Level)
+	}
+}
+
+func TestPauseAndQuit(t *testing.T) {
+	s := newTestSimulation(t, "2")
+	press(s, input.KeyEnter)
+	press(s, input.KeyP)
+	if s.State() != StatePaused {
+		t.Fatalf("state = %v, want paused", s.State())
+	}
+	s.Update(testStep)
+	if s.State() != StatePaused {
+		t.Fatalf("state = %v, want still paused", s.State())
+	}
+	press(s, input.KeyP)
+	if s.State() != StateActive {
+		t.Fatalf("state = %v, want active again", s.State())
+	}
+	press(s, input.KeyEscape)
+	press(s, input.KeyQ)
+	if s.State() != StateMenu {
+		t.Fatalf("state = %v, want the menu", s.State())
+	}
+	if s.Quit {
+		t.Error("quitting to the menu quit the game")
+	}
+}
+
+func TestWinBackToMenu(t *testing.T) {
+	s := newTestSimulation(t, "2")
+	press(s, input.KeyEnter)
+	s.Levels[0].Bricks[0].Destroyed = true
+	s.Update(testStep)
+	if s.State() != StateWin {
+		t.Fatalf("state = %v, want won", s.State())
+	}
+	press(s, input.KeyEnter)
+	if s.State() != StateMenu {
+		t.Fatalf("state = %v, want the menu", s.State())
+	}
+	if s.Levels[0].Bricks[0].Destroyed {
+		t.Error("level not restored for the menu")
+	}
+}
+
+func TestEditorPlayTest(t *testing.T) {
+	s := newTestSimulation(t, "2 2")
+	press(s, input.KeyE)
+	if s.State() != StateMenu {
+		t.Fatalf("state = %v, editor opened without SaveLevel", s.State())
+	}
+	s.SaveLevel = func(*Level) error { return nil }
+	press(s, input.KeyE)
+	if s.State() != StateEditor || s.Editor == nil || s.Editor.Level != s.Levels[0] {
+		t.Fatalf("state = %v, want the editor on the level", s.State())
+	}
+
+	press(s, input.KeyEnter)
+	if s.State() != StateActive || !s.PlayTesting() {
+		t.Fatalf("state %v, play testing %v, want a play test", s.State(), s.PlayTesting())
+	}
+	press(s, input.KeyEscape)
+	if s.State() != StateEditor || s.PlayTesting() {
+		t.Fatalf("state %v, play testing %v, want back in the editor", s.State(), s.PlayTesting())
+	}
+
+	// finishing the level goes back to the editor rather than on
+	press(s, input.KeyEnter)
+	for _, brick := range s.Levels[0].Bricks {
+		brick.Destroyed = true
+	}
+	s.Update(testStep)
+	if s.State() != StateEditor {
+		t.Fatalf("state = %v, want back in the editor", s.State())
+	}
+
+	press(s, input.KeyEscape)
+	if s.State() != StateMenu {
+		t.Fatalf("state = %v, want the menu", s.State())
+	}
+}
+
+func TestEditorRevertsUnsaved(t *testing.T) {
+	s := newTestSimulation(t, "2 2")
+	var saves int
+	s.SaveLevel = func(*Level) error {
+		saves++
+		return nil
+	}
+	press(s, input.KeyE)
+	level := s.Levels[0]
+
+	s.Editor.Paint(0, 0, "3")
+	press(s, input.KeyS)
+	if saves != 1 || s.Editor.Modified() {
+		t.Fatalf("saved %d times, modified %v, want saved once", saves, s.Editor.Modified())
+	}
+	s.Editor.EndStroke()
+	s.Editor.Paint(1, 0, "4")
+	press(s, input.KeyEscape)
+	if got := fmt.Sprint(level.Tiles); got != "[[3 2]]" {
+		t.Errorf("menu level tiles %v, want the saved [[3 2]]", got)
+	}
+
+	// the unsaved change can be had back
+	press(s, input.KeyE)
+	press(s, input.KeyZ)
+	if got := fmt.Sprint(level.Tiles); got != "[[3 4]]" {
+		t.Errorf("tiles after undo %v, want [[3 4]]", got)
+	}
+}
+
+func TestEditorActions(t *testing.T) {
+	s := newTestSimulation(t, "2 2")
+	s.SaveLevel = func(*Level) error { return nil }
+	s.Bind(input.Bindings{ActionUndo: {input.BindKey(input.KeyU)}})
+	press(s, input.KeyE)
+	level := s.Levels[0]
+
+	press(s, input.KeyRight)
+	if got := fmt.Sprint(level.Tiles); got != "[[2 2 .]]" {
+		t.Fatalf("tiles %v, want a column added", got)
+	}
+	press(s, input.Key3)
+	if s.Editor.Brush != s.Editor.Brushes()[2] {
+		t.Errorf("brush %q, want the third", s.Editor.Brush)
+	}
+	// Z is no longer undo
+	press(s, input.KeyZ)
+	if got := fmt.Sprint(level.Tiles); got != "[[2 2 .]]" {
+		t.Fatalf("tiles %v after Z, want unchanged", got)
+	}
+	press(s, input.KeyU)
+	if got := fmt.Sprint(level.Tiles); got != "[[2 2]]" {
+		t.Errorf("tiles %v after U, want the column undone", got)
+	}
+}
+
+func TestEditorReloaded(t *testing.T) {
+	s := newTestSimulation(t, "2 2")
+	s.SaveLevel = func(*Level) error { return nil }
+	press(s, input.KeyE)
+	e := s.Editor
+
+	// the file changed on disk, to what the editor has painted
+	e.Paint(0, 0, "3")
+	e.EndStroke()
+	e.Reloaded()
+	if e.Modified() {
+		t.Error("modified after reloading, want the reloaded tiles taken as saved")
+	}
+	press(s, input.KeyEscape)
+	if got := fmt.Sprint(s.Levels[0].Tiles); got != "[[3 2]]" {
+		t.Errorf("tiles %v after leaving, want the reloaded [[3 2]] kept", got)
+	}
+}
@@ -0,0 +1,582 @@
+package breakout
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Levels can also be maps made in the Tiled editor, https://www.mapeditor.org,
+// saved as TMX or JSON. The visible tile layers are the bricks, later layers
+// over earlier ones, laid out a brick per tile like any other level. A tile
+// is the brick its ID is in the original level format, counting from 0 for
+// solid, or a white brick past the ones that format has, changed by whichever
+// of these properties the tile has:
+//
+//	solid      bool
+//	color      color
+//	hitPoints  int
+//	score      int
+//	texture    string, a path in the game's assets
+//
+// Objects place things by their center. The one of type "paddle" is where
+// the paddle starts and ones of type "powerup" are spawners, dropping the
+// power-up named by their powerUp property every interval seconds. The map's
+// name, background and ballSpeed properties are the level's.
+//
+// Tilesets have to be embedded in the map, and maps can't be infinite.
+
+// tiledFlags are the bits of a global tile ID Tiled uses to flip tiles.
+const tiledFlags = 0xF0000000
+
+// defaultSpawnInterval is how often a spawner drops a power-up, in seconds,
+// if it doesn't say.
+const defaultSpawnInterval = 10
+
+// tiledMap is what levels use of a Tiled map, from either format.
+type tiledMap struct {
+	width, height         int
+	tileWidth, tileHeight int
+	infinite              bool
+	properties            tiledProperties
+	tilesets              []tiledTileset
+	// visible tile layers, each width by height global tile IDs
+	layers  [][]uint32
+	objects []tiledObject
+}
+
+type tiledTileset struct {
+	firstGID, tileCount int
+	source              string
+	// properties by tile ID
+	tiles map[int]tiledProperties
+}
+
+type tiledObject struct {
+	id                  int
+	typ                 string
+	x, y, width, height float32
+	gid                 uint32
+	properties          tiledProperties
+}
+
+// tiledProperties are custom properties by name, as text whatever their type.
+type tiledProperties map[string]string
+
+// tiledError is a problem reading a map at an offset into the file, or
+// nowhere in particular if the offset is negative.
+type tiledError struct {
+	offset int
+	err    error
+}
+
+func (e *tiledError) Error() string {
+	return e.err.Error()
+}
+
+// parseTiledLevel reads a TMX or JSON Tiled map into a level, reporting every
+// problem it finds. The level is nil if the map couldn't be read at all.
+func parseTiledLevel(data []byte, tmx bool, report func(offset int, format string, args ...interface{})) *Level {
+	read := readTiledJSON
+	if tmx {
+		read = readTMX
+	}
+	m, err := read(data)
+	if err != nil {
+		var tiledErr *tiledError
+		if errors.As(err, &tiledErr) {
+			report(tiledErr.offset, "%v", tiledErr.err)
+		} else {
+			report(-1, "%v", err)
+		}
+		return nil
+	}
+
+	if m.infinite {
+		report(-1, "infinite maps aren't supported")
+		return nil
+	}
+	if m.width <= 0 || m.height <= 0 || m.tileWidth <= 0 || m.tileHeight <= 0 {
+		report(-1, "map is %dx%d tiles of %dx%d, want a size", m.width, m.height, m.tileWidth, m.tileHeight)
+		return nil
+	}
+	for _, tileset := range m.tilesets {
+		if tileset.source != "" {
+			report(-1, "tileset %s is external, embed it in the map", tileset.source)
+		}
+	}
+
+	l := &Level{Palette: map[string]BrickType{}}
+	l.Tiles = make([][]string, m.height)
+	for y := range l.Tiles {
+		l.Tiles[y] = make([]string, m.width)
+		for x := range l.Tiles[y] {
+			l.Tiles[y][x] = eraser
+		}
+	}
+	bad := map[uint32]bool{}
+	for _, layer := range m.layers {
+		for i, gid := range layer {
+			gid &^= tiledFlags
+			if gid == 0 || bad[gid] {
+				continue
+			}
+			key := strconv.Itoa(int(gid))
+			if _, ok := l.Palette[key]; !ok {
+				brick, err := m.brick(gid)
+				if err != nil {
+					report(-1, "tile %d at row %d, column %d: %v", gid, i/m.width+1, i%m.width+1, err)
+					bad[gid] = true
+					continue
+				}
+				l.Palette[key] = brick
+			}
+			l.Tiles[i/m.width][i%m.width] = key
+		}
+	}
+
+	l.Name = m.properties["name"]
+	l.BackgroundFile = m.properties["background"]
+	if speed, ok := m.properties["ballSpeed"]; ok {
+		f, err := strconv.ParseFloat(speed, 32)
+		if err != nil || f < 0 {
+			report(-1, "ballSpeed %q isn't a speed", speed)
+		}
+		l.BallSpeed = float32(f)
+	}
+
+	for _, obj := range m.objects {
+		y := obj.y
+		if obj.gid != 0 {
+			// tile objects are placed by their bottom left corner
+			y -= obj.height
+		}
+		center := mgl32.Vec2{
+			(obj.x + obj.width/2) / float32(m.tileWidth),
+			(y + obj.height/2) / float32(m.tileHeight),
+		}
+		switch strings.ToLower(obj.typ) {
+		case "paddle":
+			l.PaddleStart = &center
+		case "powerup":
+			name := obj.properties["powerUp"]
+			typ, ok := powerUpByName(name)
+			if !ok {
+				report(-1, "object %d drops unknown power-up %q", obj.id, name)
+				continue
+			}
+			spawner := Spawner{PowerUp: typ, Position: center, Interval: defaultSpawnInterval}
+			if interval, ok := obj.properties["interval"]; ok {
+				f, err := strconv.ParseFloat(interval, 32)
+				if err != nil || f <= 0 {
+					report(-1, "object %d has interval %q, want a positive number of seconds", obj.id, interval)
+					continue
+				}
+				spawner.Interval = float32(f)
+			}
+			l.Spawners = append(l.Spawners, spawner)
+		}
+	}
+	return l
+}
+
+// brick is the brick a global tile ID is.
+func (m *tiledMap) brick(gid uint32) (BrickType, error) {
+	var tileset *tiledTileset
+	for i := range m.tilesets {
+		if t := &m.tilesets[i]; uint32(t.firstGID) <= gid && (tileset == nil || t.firstGID > tileset.firstGID) {
+			tileset = t
+		}
+	}
+	if tileset == nil {
+		return BrickType{}, errors.New("isn't in a tileset")
+	}
+	id := int(gid) - tileset.firstGID
+	if tileset.tileCount > 0 && id >= tileset.tileCount {
+		return BrickType{}, errors.New("isn't in a tileset")
+	}
+
+	brick, ok := legacyPalette[strconv.Itoa(id+1)]
+	if !ok {
+		brick = BrickType{Color: DefaultGameObjectColor, HitPoints: 1, Score: 10}
+	}
+	props := tileset.tiles[id]
+	var err error
+	if v, ok := props["solid"]; ok && err == nil {
+		brick.Solid, err = strconv.ParseBool(v)
+	}
+	if v, ok := props["color"]; ok && err == nil {
+		brick.Color, err = parseTiledColor(v)
+	}
+	if v, ok := props["hitPoints"]; ok && err == nil {
+		brick.HitPoints, err = strconv.Atoi(v)
+	}
+	if v, ok := props["score"]; ok && err == nil {
+		brick.Score, err = strconv.Atoi(v)
+	}
+	if v, ok := props["texture"]; ok {
+		brick.Texture = v
+	}
+	if err != nil {
+		return brick, err
+	}
+	if brick.HitPoints < 1 {
+		return brick, fmt.Errorf("has %d hit points, want at least 1", brick.HitPoints)
+	}
+	return brick, nil
+}
+
+// parseTiledColor reads a color as Tiled writes them, #RRGGBB or #AARRGGBB.
+// Alpha is ignored.
+func parseTiledColor(s string) (mgl32.Vec3, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 8 {
+		hex = hex[2:]
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return mgl32.Vec3{}, fmt.Errorf("%q isn't a color", s)
+	}
+	return mgl32.Vec3{
+		float32(v>>16&0xff) / 255,
+		float32(v>>8&0xff) / 255,
+		float32(v&0xff) / 255,
+	}, nil
+}
+
+// decodeTiledData reads a layer's tiles in any of the encodings Tiled saves
+// them in but zstd compression.
+func decodeTiledData(encoding, compression, text string, count int) ([]uint32, error) {
+	var gids []uint32
+	switch encoding {
+	case "csv":
+		for _, field := range strings.Split(strings.TrimSpace(text), ",") {
+			gid, err := strconv.ParseUint(strings.TrimSpace(field), 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			gids = append(gids, uint32(gid))
+		}
+	case "base64":
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+		if err != nil {
+			return nil, err
+		}
+		var r io.ReadCloser
+		switch compression {
+		case "":
+		case "zlib":
+			r, err = zlib.NewReader(bytes.NewReader(data))
+		case "gzip":
+			r, err = gzip.NewReader(bytes.NewReader(data))
+		default:
+			return nil, fmt.Errorf("%s compression isn't supported", compression)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			data, err = ioutil.ReadAll(r)
+			r.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+		for ; len(data) >= 4; data = data[4:] {
+			gids = append(gids, binary.LittleEndian.Uint32(data))
+		}
+	default:
+		return nil, fmt.Errorf("%s encoding isn't supported", encoding)
+	}
+	if len(gids) != count {
+		return nil, fmt.Errorf("layer has %d tiles, want %d", len(gids), count)
+	}
+	return gids, nil
+}
+
+// TMX, Tiled's XML format
+
+type tmxMap struct {
+	Width      int              `xml:"width,attr"`
+	Height     int              `xml:"height,attr"`
+	TileWidth  int              `xml:"tilewidth,attr"`
+	TileHeight int              `xml:"tileheight,attr"`
+	Infinite   int              `xml:"infinite,attr"`
+	Properties []tmxProperty    `xml:"properties>property"`
+	Tilesets   []tmxTileset     `xml:"tileset"`
+	Layers     []tmxLayer       `xml:"layer"`
+	Objects    []tmxObjectLayer `xml:"objectgroup"`
+}
+
+type tmxProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+	// multiline strings are the element's text instead
+	Text string `xml:",chardata"`
+}
+
+type tmxTileset struct {
+	FirstGID  int    `xml:"firstgid,attr"`
+	TileCount int    `xml:"tilecount,attr"`
+	Source    string `xml:"source,attr"`
+	Tiles     []struct {
+		ID         int           `xml:"id,attr"`
+		Properties []tmxProperty `xml:"properties>property"`
+	} `xml:"tile"`
+}
+
+type tmxLayer struct {
+	Name    string `xml:"name,attr"`
+	Visible string `xml:"visible,attr"`
+	Data    struct {
+		Encoding    string `xml:"encoding,attr"`
+		Compression string `xml:"compression,attr"`
+		Tiles       []struct {
+			GID uint32 `xml:"gid,attr"`
+		} `xml:"tile"`
+		Text string `xml:",chardata"`
+	} `xml:"data"`
+}
+
+type tmxObjectLayer struct {
+	Visible string `xml:"visible,attr"`
+	Objects []struct {
+		ID         int           `xml:"id,attr"`
+		Type       string        `xml:"type,attr"`
+		Class      string        `xml:"class,attr"`
+		X          float32       `xml:"x,attr"`
+		Y          float32       `xml:"y,attr"`
+		Width      float32       `xml:"width,attr"`
+		Height     float32       `xml:"height,attr"`
+		GID        uint32        `xml:"gid,attr"`
+		Properties []tmxProperty `xml:"properties>property"`
+	} `xml:"object"`
+}
+
+func tmxProperties(properties []tmxProperty) tiledProperties {
+	props := tiledProperties{}
+	for _, p := range properties {
+		props[p.Name] = p.Value
+		if p.Value == "" {
+			props[p.Name] = p.Text
+		}
+	}
+	return props
+}
+
+func readTMX(data []byte) (*tiledMap, error) {
+	var tmx tmxMap
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&tmx); err != nil {
+		return nil, &tiledError{offset: int(dec.InputOffset()), err: err}
+	}
+
+	m := &tiledMap{
+		width:      tmx.Width,
+		height:     tmx.Height,
+		tileWidth:  tmx.TileWidth,
+		tileHeight: tmx.TileHeight,
+		infinite:   tmx.Infinite != 0,
+		properties: tmxProperties(tmx.Properties),
+	}
+	for _, t := range tmx.Tilesets {
+		tileset := tiledTileset{firstGID: t.FirstGID, tileCount: t.TileCount, source: t.Source, tiles: map[int]tiledProperties{}}
+		for _, tile := range t.Tiles {
+			tileset.tiles[tile.ID] = tmxProperties(tile.Properties)
+		}
+		m.tilesets = append(m.tilesets, tileset)
+	}
+	for _, layer := range tmx.Layers {
+		if layer.Visible == "0" {
+			continue
+		}
+		var gids []uint32
+		if layer.Data.Encoding == "" {
+			for _, tile := range layer.Data.Tiles {
+				gids = append(gids, tile.GID)
+			}
+			if len(gids) != m.width*m.height {
+				return nil, fmt.Errorf("layer %s has %d tiles, want %d", layer.Name, len(gids), m.width*m.height)
+			}
+		} else {
+			var err error
+			gids, err = decodeTiledData(layer.Data.Encoding, layer.Data.Compression, layer.Data.Text, m.width*m.height)
+			if err != nil {
+				return nil, fmt.Errorf("layer %s: %w", layer.Name, err)
+			}
+		}
+		m.layers = append(m.layers, gids)
+	}
+	for _, layer := range tmx.Objects {
+		if layer.Visible == "0" {
+			continue
+		}
+		for _, o := range layer.Objects {
+			typ := o.Type
+			if typ == "" {
+				typ = o.Class
+			}
+			m.objects = append(m.objects, tiledObject{
+				id: o.ID, typ: typ,
+				x: o.X, y: o.Y, width: o.Width, height: o.Height,
+				gid:        o.GID,
+				properties: tmxProperties(o.Properties),
+			})
+		}
+	}
+	return m, nil
+}
+
+// Tiled's JSON format
+
+type tiledJSONMap struct {
+	Type       string              `json:"type"`
+	Width      int                 `json:"width"`
+	Height     int                 `json:"height"`
+	TileWidth  int                 `json:"tilewidth"`
+	TileHeight int                 `json:"tileheight"`
+	Infinite   bool                `json:"infinite"`
+	Properties []tiledJSONProperty `json:"properties"`
+	Tilesets   []struct {
+		FirstGID  int    `json:"firstgid"`
+		TileCount int    `json:"tilecount"`
+		Source    string `json:"source"`
+		Tiles     []struct {
+			ID         int                 `json:"id"`
+			Properties []tiledJSONProperty `json:"properties"`
+		} `json:"tiles"`
+	} `json:"tilesets"`
+	Layers []struct {
+		Type        string          `json:"type"`
+		Name        string          `json:"name"`
+		Visible     *bool           `json:"visible"`
+		Encoding    string          `json:"encoding"`
+		Compression string          `json:"compression"`
+		Data        json.RawMessage `json:"data"`
+		Objects     []struct {
+			ID         int                 `json:"id"`
+			Type       string              `json:"type"`
+			Class      string              `json:"class"`
+			X          float32             `json:"x"`
+			Y          float32             `json:"y"`
+			Width      float32             `json:"width"`
+			Height     float32             `json:"height"`
+			GID        uint32              `json:"gid"`
+			Properties []tiledJSONProperty `json:"properties"`
+		} `json:"objects"`
+	} `json:"layers"`
+}
+
+type tiledJSONProperty struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+func tiledJSONProperties(properties []tiledJSONProperty) tiledProperties {
+	props := tiledProperties{}
+	for _, p := range properties {
+		switch v := p.Value.(type) {
+		case string:
+			props[p.Name] = v
+		case float64:
+			props[p.Name] = strconv.FormatFloat(v, 'g', -1, 64)
+		default:
+			props[p.Name] = fmt.Sprint(v)
+		}
+	}
+	return props
+}
+
+// isTiledJSON reports whether a JSON file is a Tiled map rather than a level
+// in the game's own format.
+func isTiledJSON(data []byte) bool {
+	var probe struct {
+		Type string `json:"type"`
+	}
+	json.Unmarshal(data, &probe)
+	return probe.Type == "map"
+}
+
+func readTiledJSON(data []byte) (*tiledMap, error) {
+	var tj tiledJSONMap
+	if err := json.Unmarshal(data, &tj); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			return nil, &tiledError{offset: int(syntaxErr.Offset), err: err}
+		case errors.As(err, &typeErr):
+			return nil, &tiledError{offset: int(typeErr.Offset), err: err}
+		}
+		return nil, err
+	}
+
+	m := &tiledMap{
+		width:      tj.Width,
+		height:     tj.Height,
+		tileWidth:  tj.TileWidth,
+		tileHeight: tj.TileHeight,
+		infinite:   tj.Infinite,
+		properties: tiledJSONProperties(tj.Properties),
+	}
+	for _, t := range tj.Tilesets {
+		tileset := tiledTileset{firstGID: t.FirstGID, tileCount: t.TileCount, source: t.Source, tiles: map[int]tiledProperties{}}
+		for _, tile := range t.Tiles {
+			tileset.tiles[tile.ID] = tiledJSONProperties(tile.Properties)
+		}
+		m.tilesets = append(m.tilesets, tileset)
+	}
+	for _, layer := range tj.Layers {
+		if layer.Visible != nil && !*layer.Visible {
+			continue
+		}
+		switch layer.Type {
+		case "tilelayer":
+			var gids []uint32
+			if layer.Encoding == "base64" {
+				var text string
+				if err := json.Unmarshal(layer.Data, &text); err != nil {
+					return nil, fmt.Errorf("layer %s: %w", layer.Name, err)
+				}
+				var err error
+				if gids, err = decodeTiledData("base64", layer.Compression, text, m.width*m.height); err != nil {
+					return nil, fmt.Errorf("layer %s: %w", layer.Name, err)
+				}
+			} else {
+				if err := json.Unmarshal(layer.Data, &gids); err != nil {
+					return nil, fmt.Errorf("layer %s: %w", layer.Name, err)
+				}
+				if len(gids) != m.width*m.height {
+					return nil, fmt.Errorf("layer %s has %d tiles, want %d", layer.Name, len(gids), m.width*m.height)
+				}
+			}
+			m.layers = append(m.layers, gids)
+		case "objectgroup":
+			for _, o := range layer.Objects {
+				typ := o.Type
+				if typ == "" {
+					typ = o.Class
+				}
+				m.objects = append(m.objects, tiledObject{
+					id: o.ID, typ: typ,
+					x: o.X, y: o.Y, width: o.Width, height: o.Height,
+					gid:        o.GID,
+					properties: tiledJSONProperties(o.Properties),
+				})
+			}
+		}
+	}
+	return m, nil
+}
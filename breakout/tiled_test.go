@@ -0,0 +1,420 @@
+package breakout
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// tiledBase64 encodes global tile IDs as Tiled does with the base64
+// encoding, compressed with zlib, gzip or nothing.
+func tiledBase64(t *testing.T, compression string, gids ...uint32) string {
+	t.Helper()
+	var raw bytes.Buffer
+	binary.Write(&raw, binary.LittleEndian, gids)
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch compression {
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	default:
+		buf = raw
+	}
+	if w != nil {
+		if _, err := w.Write(raw.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestDecodeTiledData(t *testing.T) {
+	gids := []uint32{1, 0, 6, 0x80000002, 0, 3}
+	tests := []struct {
+		name                  string
+		encoding, compression string
+		text                  string
+		count                 int
+		err                   string
+	}{
+		{"csv", "csv", "", "1,0,6,2147483650,0,3", 6, ""},
+		{"csv over lines", "csv", "", "\n1,0,6,\n2147483650, 0, 3\n", 6, ""},
+		{"base64", "base64", "", tiledBase64(t, "", gids...), 6, ""},
+		{"zlib", "base64", "zlib", tiledBase64(t, "zlib", gids...), 6, ""},
+		{"gzip", "base64", "gzip", tiledBase64(t, "gzip", gids...), 6, ""},
+		{"padded base64", "base64", "", "\n   " + tiledBase64(t, "", gids...) + "\n  ", 6, ""},
+		{"csv too few", "csv", "", "1,0,6", 6, "layer has 3 tiles, want 6"},
+		{"csv too many", "csv", "", "1,0,6,2,0,3,4", 6, "layer has 7 tiles, want 6"},
+		{"base64 too few", "base64", "zlib", tiledBase64(t, "zlib", gids[:4]...), 6, "layer has 4 tiles, want 6"},
+		{"csv not a number", "csv", "", "1,0,six,2,0,3", 6, `strconv.ParseUint: parsing "six": invalid syntax`},
+		{"not base64", "base64", "", "!!!", 6, "illegal base64 data at input byte 0"},
+		{"not zlib", "base64", "zlib", tiledBase64(t, "", gids...), 6, "zlib: invalid header"},
+		{"not gzip", "base64", "gzip", tiledBase64(t, "zlib", gids...), 6, "gzip: invalid header"},
+		{"zstd", "base64", "zstd", tiledBase64(t, "", gids...), 6, "zstd compression isn't supported"},
+		{"xml", "xml", "", "", 6, "xml encoding isn't supported"},
+	}
+	for _, test := range tests {
+		got, err := decodeTiledData(test.encoding, test.compression, test.text, test.count)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: error %v, want %s", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil || fmt.Sprint(got) != fmt.Sprint(gids) {
+			t.Errorf("%s: decoded %v, %v, want %v", test.name, got, err, gids)
+		}
+	}
+}
+
+// testTileset is the tileset of the maps in tests: the original bricks,
+// then a tough one, a solid textured one and a broken one.
+const testTileset = `
+ <tileset firstgid="1" name="bricks" tilewidth="32" tileheight="16" tilecount="8">
+  <tile id="5"><properties>
+   <property name="color" type="color" value="#ff336699"/>
+   <property name="hitPoints" type="int" value="3"/>
+   <property name="score" type="int" value="70"/>
+  </properties></tile>
+  <tile id="6"><properties>
+   <property name="solid" type="bool" value="true"/>
+   <property name="texture" value="textures/steel.png"/>
+  </properties></tile>
+  <tile id="7"><properties>
+   <property name="hitPoints" type="int" value="0"/>
+  </properties></tile>
+ </tileset>`
+
+// tmxLevel is a 3 by 2 map of 32 by 16 tiles with the test tileset.
+func tmxLevel(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" width="3" height="2" tilewidth="32" tileheight="16" infinite="0">` +
+		testTileset + body + "\n</map>\n"
+}
+
+func TestParseTiledLevel(t *testing.T) {
+	tough := BrickType{Color: mgl32.Vec3{.2, .4, .6}, HitPoints: 3, Score: 70}
+	steel := BrickType{Solid: true, Color: DefaultGameObjectColor, Texture: "textures/steel.png", HitPoints: 1, Score: 10}
+	tests := []struct {
+		name     string
+		tmx      bool
+		data     string
+		tiles    string
+		palette  map[string]BrickType
+		objects  string
+		reported []string
+	}{
+		{
+			name:    "csv",
+			tmx:     true,
+			data:    tmxLevel(`<layer name="bricks"><data encoding="csv">1,2,3,4,5,0</data></layer>`),
+			tiles:   "[[1 2 3] [4 5 .]]",
+			palette: map[string]BrickType{"1": legacyPalette["1"], "2": legacyPalette["2"], "3": legacyPalette["3"], "4": legacyPalette["4"], "5": legacyPalette["5"]},
+			objects: "<nil> []",
+		},
+		{
+			name:    "tile elements",
+			tmx:     true,
+			data:    tmxLevel(`<layer name="bricks"><data><tile gid="6"/><tile/><tile gid="7"/><tile/><tile/><tile gid="2"/></data></layer>`),
+			tiles:   "[[6 . 7] [. . 2]]",
+			palette: map[string]BrickType{"2": legacyPalette["2"], "6": tough, "7": steel},
+			objects: "<nil> []",
+		},
+		{
+			name: "flipped tiles",
+			tmx:  true,
+			// flipped horizontally, vertically, diagonally and all three
+			data:    tmxLevel(`<layer name="bricks"><data encoding="csv">2147483654,1073741830,536870918,3758096390,6,0</data></layer>`),
+			tiles:   "[[6 6 6] [6 6 .]]",
+			palette: map[string]BrickType{"6": tough},
+			objects: "<nil> []",
+		},
+		{
+			name: "layers over layers",
+			tmx:  true,
+			data: tmxLevel(`
+ <layer name="under"><data encoding="csv">2,2,2,2,2,2</data></layer>
+ <layer name="hidden" visible="0"><data encoding="csv">1,1,1,1,1,1</data></layer>
+ <layer name="over"><data encoding="base64" compression="zlib">` + tiledBase64(t, "zlib", 0, 7, 0, 6, 0, 0) + `</data></layer>`),
+			tiles:   "[[2 7 2] [6 2 2]]",
+			palette: map[string]BrickType{"2": legacyPalette["2"], "6": tough, "7": steel},
+			objects: "<nil> []",
+		},
+		{
+			name: "objects",
+			tmx:  true,
+			data: tmxLevel(`
+ <layer name="bricks"><data encoding="base64" compression="gzip">` + tiledBase64(t, "gzip", 2, 2, 2, 0, 0, 0) + `</data></layer>
+ <objectgroup name="objects">
+  <object id="1" type="paddle" x="16" y="24"><point/></object>
+  <object id="2" type="powerup" x="0" y="0" width="32" height="16">
+   <properties><property name="powerUp" value="sticky"/></properties>
+  </object>
+  <object id="3" class="PowerUp" gid="2" x="32" y="32" width="32" height="16">
+   <properties>
+    <property name="powerUp" value="chaos"/>
+    <property name="interval" type="float" value="4.5"/>
+   </properties>
+  </object>
+  <object id="4" type="powerup" x="64" y="0">
+   <properties><property name="powerUp" value="bananas"/></properties>
+  </object>
+  <object id="5" type="powerup" x="64" y="0">
+   <properties>
+    <property name="powerUp" value="speed"/>
+    <property name="interval" value="never"/>
+   </properties>
+  </object>
+  <object id="6" type="rock" x="64" y="0"/>
+ </objectgroup>
+ <objectgroup name="hidden" visible="0">
+  <object id="7" type="paddle" x="0" y="0"><point/></object>
+ </objectgroup>`),
+			tiles:   "[[2 2 2] [. . .]]",
+			palette: map[string]BrickType{"2": legacyPalette["2"]},
+			objects: fmt.Sprint(mgl32.Vec2{.5, 1.5}, []Spawner{
+				{PowerUp: PowerUpSticky, Position: mgl32.Vec2{.5, .5}, Interval: defaultSpawnInterval},
+				{PowerUp: PowerUpChaos, Position: mgl32.Vec2{1.5, 1.5}, Interval: 4.5},
+			}),
+			reported: []string{
+				`-1: object 4 drops unknown power-up "bananas"`,
+				`-1: object 5 has interval "never", want a positive number of seconds`,
+			},
+		},
+		{
+			name:    "bad tiles",
+			tmx:     true,
+			data:    tmxLevel(`<layer name="bricks"><data encoding="csv">8,2,8,9,0,0</data></layer>`),
+			tiles:   "[[. 2 .] [. . .]]",
+			palette: map[string]BrickType{"2": legacyPalette["2"]},
+			objects: "<nil> []",
+			reported: []string{
+				"-1: tile 8 at row 1, column 1: has 0 hit points, want at least 1",
+				"-1: tile 9 at row 2, column 1: isn't in a tileset",
+			},
+		},
+		{
+			name: "json",
+			data: `{"type": "map", "width": 3, "height": 2, "tilewidth": 32, "tileheight": 16,
+ "properties": [{"name": "name", "type": "string", "value": "Tiny"}, {"name": "ballSpeed", "type": "float", "value": 250}],
+ "tilesets": [{"firstgid": 1, "tilecount": 8, "tiles": [
+  {"id": 5, "properties": [{"name": "color", "type": "color", "value": "#336699"}, {"name": "hitPoints", "type": "int", "value": 3}, {"name": "score", "type": "int", "value": 70}]},
+  {"id": 6, "properties": [{"name": "solid", "type": "bool", "value": true}, {"name": "texture", "type": "string", "value": "textures/steel.png"}]}
+ ]}],
+ "layers": [
+  {"type": "tilelayer", "name": "under", "data": [1, 0, 0, 0, 0, 1]},
+  {"type": "tilelayer", "name": "hidden", "visible": false, "data": [3, 3, 3, 3, 3, 3]},
+  {"type": "tilelayer", "name": "over", "encoding": "base64", "data": "` + tiledBase64(t, "", 0, 3221225478, 7, 0, 0, 0) + `"},
+  {"type": "objectgroup", "objects": [{"id": 1, "type": "paddle", "x": 48, "y": 16, "point": true}]}
+ ]}`,
+			tiles:   "[[1 6 7] [. . 1]]",
+			palette: map[string]BrickType{"1": legacyPalette["1"], "6": tough, "7": steel},
+			objects: fmt.Sprint(mgl32.Vec2{1.5, 1}, []Spawner(nil)),
+		},
+	}
+	for _, test := range tests {
+		var reported []string
+		l := parseTiledLevel([]byte(test.data), test.tmx, func(offset int, format string, args ...interface{}) {
+			reported = append(reported, fmt.Sprintf("%d: ", offset)+fmt.Sprintf(format, args...))
+		})
+		if fmt.Sprint(reported) != fmt.Sprint(test.reported) {
+			t.Errorf("%s: reported %q, want %q", test.name, reported, test.reported)
+		}
+		if l == nil {
+			t.Errorf("%s: no level", test.name)
+			continue
+		}
+		if got := fmt.Sprint(l.Tiles); got != test.tiles {
+			t.Errorf("%s: tiles %s, want %s", test.name, got, test.tiles)
+		}
+		// maps print sorted, so equal palettes print the same
+		if fmt.Sprint(l.Palette) != fmt.Sprint(test.palette) {
+			t.Errorf("%s: palette %v, want %v", test.name, l.Palette, test.palette)
+		}
+		var paddle interface{} = l.PaddleStart
+		if l.PaddleStart != nil {
+			paddle = *l.PaddleStart
+		}
+		if got := fmt.Sprint(paddle, l.Spawners); got != test.objects {
+			t.Errorf("%s: paddle and spawners %s, want %s", test.name, got, test.objects)
+		}
+	}
+}
+
+func TestParseTiledLevelErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		tmx  bool
+		data string
+		// the problem is reported just past the first match of after, or at
+		// -1 if it is empty
+		after    string
+		reported string
+	}{
+		{
+			name:     "tmx syntax",
+			tmx:      true,
+			data:     "<map width=\"3\">\n <layer name=\"bricks\">\n </map>\n",
+			after:    "</map>",
+			reported: "XML syntax error on line 3: element <layer> closed by </map>",
+		},
+		{
+			name:     "tmx truncated",
+			tmx:      true,
+			data:     "<map width=\"3\">\n <layer",
+			after:    "<layer",
+			reported: "XML syntax error on line 2: unexpected EOF",
+		},
+		{
+			name:     "json syntax",
+			data:     `{"type": "map", "width": 3,, "height": 2}`,
+			after:    ",,",
+			reported: "invalid character ',' looking for beginning of object key string",
+		},
+		{
+			name:     "json type",
+			data:     `{"type": "map", "width": "wide", "height": 2}`,
+			after:    `"wide"`,
+			reported: "json: cannot unmarshal string into Go struct field tiledJSONMap.width of type int",
+		},
+		{
+			name:     "tmx layer too short",
+			tmx:      true,
+			data:     tmxLevel(`<layer name="bricks"><data encoding="csv">1,2,3</data></layer>`),
+			reported: "layer bricks: layer has 3 tiles, want 6",
+		},
+		{
+			name:     "tmx tile elements too many",
+			tmx:      true,
+			data:     tmxLevel(`<layer name="bricks"><data>` + strings.Repeat(`<tile gid="1"/>`, 7) + `</data></layer>`),
+			reported: "layer bricks has 7 tiles, want 6",
+		},
+		{
+			name:     "json layer too long",
+			data:     `{"type": "map", "width": 3, "height": 2, "tilewidth": 32, "tileheight": 16, "layers": [{"type": "tilelayer", "name": "bricks", "data": [1, 1, 1, 1, 1, 1, 1]}]}`,
+			reported: "layer bricks has 7 tiles, want 6",
+		},
+		{
+			name:     "hidden layer too short",
+			tmx:      true,
+			data:     tmxLevel(`<layer name="hidden" visible="0"><data encoding="csv">1</data></layer><layer name="bricks"><data encoding="csv">1,2,3,4,5,6,7</data></layer>`),
+			reported: "layer bricks: layer has 7 tiles, want 6",
+		},
+		{
+			name:     "infinite",
+			tmx:      true,
+			data:     `<map width="3" height="2" tilewidth="32" tileheight="16" infinite="1"></map>`,
+			reported: "infinite maps aren't supported",
+		},
+	}
+	for _, test := range tests {
+		var reported []string
+		var offsets []int
+		l := parseTiledLevel([]byte(test.data), test.tmx, func(offset int, format string, args ...interface{}) {
+			reported = append(reported, fmt.Sprintf(format, args...))
+			offsets = append(offsets, offset)
+		})
+		if l != nil {
+			t.Errorf("%s: parsed a level", test.name)
+		}
+		if fmt.Sprint(reported) != fmt.Sprint([]string{test.reported}) {
+			t.Errorf("%s: reported %q, want %q", test.name, reported, test.reported)
+			continue
+		}
+		want := -1
+		if test.after != "" {
+			want = strings.Index(test.data, test.after) + len(test.after)
+		}
+		if offsets[0] != want {
+			t.Errorf("%s: reported at %d, want %d", test.name, offsets[0], want)
+		}
+	}
+}
+
+func TestTiledLevelFile(t *testing.T) {
+	const width, height = 480, 128
+	l := NewLevel(nil)
+	if err := l.Load(os.DirFS("."), "levels/6.tmx", width, height); err != nil {
+		t.Fatal(err)
+	}
+	if l.Name != "Funnel" || l.BallSpeed != 380 {
+		t.Errorf("level %q at speed %v, want Funnel at 380", l.Name, l.BallSpeed)
+	}
+	if l.PaddleStart == nil || *l.PaddleStart != (mgl32.Vec2{7.5, 15.75}) {
+		t.Errorf("paddle starts at %v, want [7.5 15.75]", l.PaddleStart)
+	}
+	want := []Spawner{{PowerUp: PowerUpSticky, Position: mgl32.Vec2{7.5, 2.5}, Interval: 12}}
+	if fmt.Sprint(l.Spawners) != fmt.Sprint(want) {
+		t.Errorf("spawners %v, want %v", l.Spawners, want)
+	}
+
+	// the bricks laid out as the map has them: # solid, the rest by what
+	// they are worth
+	worth := map[int]byte{10: '2', 20: '3', 30: '4', 50: '5', 40: '6'}
+	bricks := func(l *Level) string {
+		grid := make([][]byte, len(l.Tiles))
+		for y := range grid {
+			grid[y] = bytes.Repeat([]byte{'.'}, len(l.Tiles[y]))
+		}
+		for _, brick := range l.Bricks {
+			x, y := int(brick.Position.X())/(width/15), int(brick.Position.Y())/(height/8)
+			switch {
+			case brick.IsSolid:
+				grid[y][x] = '#'
+			case brick.Points == 40 && brick.HitPoints == 2:
+				grid[y][x] = worth[brick.Points]
+			case brick.HitPoints == 1:
+				grid[y][x] = worth[brick.Points]
+			default:
+				grid[y][x] = '?'
+			}
+		}
+		return string(bytes.Join(grid, []byte("\n")))
+	}
+	const funnel = "###############\n" +
+		"#666666.666666#\n" +
+		"#55555...55555#\n" +
+		"#4444.....4444#\n" +
+		".33333...33333.\n" +
+		".222222.222222.\n" +
+		"..22222222222..\n" +
+		"..............."
+	if got := bricks(l); got != funnel {
+		t.Errorf("bricks\n%s\nwant\n%s", got, funnel)
+	}
+
+	// saved in the game's format, the level comes back the same
+	data, err := l.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diagnostics := ValidateLevel("6.tmx.edited.json", data); len(diagnostics) > 0 {
+		t.Fatalf("saved level doesn't validate: %v", diagnostics)
+	}
+	saved := NewLevel(nil)
+	if err := saved.Load(fstest.MapFS{"6.json": {Data: data}}, "6.json", width, height); err != nil {
+		t.Fatal(err)
+	}
+	if got := bricks(saved); got != funnel {
+		t.Errorf("saved bricks\n%s\nwant\n%s", got, funnel)
+	}
+	if fmt.Sprint(*saved.PaddleStart, saved.Spawners, saved.BallSpeed) != fmt.Sprint(*l.PaddleStart, l.Spawners, l.BallSpeed) {
+		t.Errorf("saved level has paddle %v, spawners %v and speed %v, want %v, %v and %v",
+			*saved.PaddleStart, saved.Spawners, saved.BallSpeed, *l.PaddleStart, l.Spawners, l.BallSpeed)
+	}
+}
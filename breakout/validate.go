@@ -0,0 +1,444 @@
+package breakout
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Diagnostic is a problem found in a level file. Line and Column count from
+// 1 and are 0 for problems with the level as a whole.
+type Diagnostic struct {
+	File         string
+	Line, Column int
+	Message      string
+}
+
+func (d Diagnostic) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s", d.File, d.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, d.Message)
+}
+
+// LevelError is a level file that failed validation.
+type LevelError struct {
+	File        string
+	Diagnostics []Diagnostic
+}
+
+func (e *LevelError) Error() string {
+	lines := make([]string, len(e.Diagnostics))
+	for i, d := range e.Diagnostics {
+		lines[i] = d.String()
+	}
+	return "invalid level " + e.File + ":\n" + strings.Join(lines, "\n")
+}
+
+// ValidateLevels validates every level file in dir of fsys, as loading would
+// read it.
+func ValidateLevels(fsys fs.FS, dir string) ([]Diagnostic, error) {
+	files, err := levelFiles(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var diagnostics []Diagnostic
+	for _, file := range files {
+		source, data, err := readLevel(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		diagnostics = append(diagnostics, ValidateLevel(source, data)...)
+	}
+	return diagnostics, nil
+}
+
+// ValidateLevel reports every problem with the contents of a level file, in
+// any format: tokens that aren't bricks, rows of different lengths, JSON
+// that doesn't match the format, levels with no bricks at all and levels
+// with no bricks that can be destroyed. The file name is only for the
+// diagnostics.
+func ValidateLevel(file string, data []byte) []Diagnostic {
+	v := &validator{file: file, data: data}
+	switch levelFormat(data) {
+	case formatJSON:
+		v.validateJSON()
+	case formatTMX:
+		v.validateTiled(true)
+	case formatTiledJSON:
+		v.validateTiled(false)
+	default:
+		v.validateLegacy()
+	}
+	sort.SliceStable(v.diagnostics, func(i, j int) bool {
+		a, b := v.diagnostics[i], v.diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return v.diagnostics
+}
+
+type validator struct {
+	file        string
+	data        []byte
+	diagnostics []Diagnostic
+}
+
+// errorAt reports a problem at a byte offset into the file, or the whole
+// file for a negative offset.
+func (v *validator) errorAt(offset int, format string, args ...interface{}) {
+	d := Diagnostic{File: v.file, Message: fmt.Sprintf(format, args...)}
+	if offset >= 0 {
+		if offset > len(v.data) {
+			offset = len(v.data)
+		}
+		d.Line = bytes.Count(v.data[:offset], []byte("\n")) + 1
+		d.Column = offset - bytes.LastIndexByte(v.data[:offset], '\n')
+	}
+	v.diagnostics = append(v.diagnostics, d)
+}
+
+// tile is a brick key and where it is in the file.
+type tile struct {
+	key    string
+	offset int
+}
+
+// checkBricks reports what is wrong with the rows of tiles as a whole.
+// rowEnds are where each row ends, for rows that are short.
+func (v *validator) checkBricks(rows [][]tile, rowEnds []int, breakable func(key string) bool) {
+	if len(rows) == 0 {
+		v.errorAt(-1, "level has no bricks")
+		return
+	}
+	want := len(rows[0])
+	for i, row := range rows[1:] {
+		if len(row) > want {
+			v.errorAt(row[want].offset, "row has %d bricks, want %d like the first row", len(row), want)
+		} else if len(row) < want {
+			v.errorAt(rowEnds[i+1], "row has %d bricks, want %d like the first row", len(row), want)
+		}
+	}
+	for _, row := range rows {
+		for _, t := range row {
+			if breakable(t.key) {
+				return
+			}
+		}
+	}
+	v.errorAt(-1, "level has no bricks that can be destroyed")
+}
+
+// fields splits line into tokens, each with its offset from the start of the
+// line.
+func fields(line string) []tile {
+	var tiles []tile
+	start := -1
+	for i, r := range line + " " {
+		space := r == ' ' || r == '\t' || r == '\r'
+		if !space && start < 0 {
+			start = i
+		} else if space && start >= 0 {
+			tiles = append(tiles, tile{key: line[start:i], offset: start})
+			start = -1
+		}
+	}
+	return tiles
+}
+
+func (v *validator) validateLegacy() {
+	var rows [][]tile
+	var rowEnds []int
+	scanner := bufio.NewScanner(bytes.NewReader(v.data))
+	offset := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		var row []tile
+		for _, t := range fields(line) {
+			t.offset += offset
+			code, err := strconv.Atoi(t.key)
+			if err != nil {
+				v.errorAt(t.offset, "%q is not a brick code", t.key)
+				t.key = "0"
+			} else if _, ok := legacyPalette[t.key]; !ok && code != 0 {
+				v.errorAt(t.offset, "unknown brick code %s, want 0 to 5", t.key)
+			}
+			row = append(row, t)
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+			rowEnds = append(rowEnds, offset+len(strings.TrimRight(line, " \t\r")))
+		}
+		offset += len(line) + 1
+	}
+	if err := scanner.Err(); err != nil {
+		v.errorAt(offset, "%v", err)
+		return
+	}
+	v.checkBricks(rows, rowEnds, func(key string) bool {
+		brick, ok := legacyPalette[key]
+		return ok && !brick.Solid
+	})
+}
+
+func (v *validator) validateJSON() {
+	dec := json.NewDecoder(bytes.NewReader(v.data))
+	var lf levelFile
+	// where each field's value starts
+	offsets := map[string]int{}
+	paletteOffsets := map[string]int{}
+	var rows [][]tile
+	var rowEnds []int
+
+	// values that didn't decode, so aren't checked any further
+	bad := map[string]bool{}
+
+	// every decode error but a type error leaves the decoder unusable. Type
+	// errors are reported at the start of the value named field since their
+	// own offsets are relative to it.
+	fatal := func(err error, offset int, field string) bool {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case err == nil:
+			return false
+		case errors.As(err, &typeErr):
+			if typeErr.Field != "" {
+				field += "." + typeErr.Field
+			}
+			v.errorAt(offset, "%s is a %s, want %s", field, typeErr.Value, typeErr.Type)
+			bad[field] = true
+			return false
+		case errors.As(err, &syntaxErr):
+			v.errorAt(int(syntaxErr.Offset), "%v", err)
+		case err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF):
+			v.errorAt(len(v.data), "unexpected end of file")
+		default:
+			v.errorAt(int(dec.InputOffset()), "%v", err)
+		}
+		return true
+	}
+	delim := func(want json.Delim) bool {
+		offset := v.next(dec)
+		tok, err := dec.Token()
+		if fatal(err, offset, "") {
+			return false
+		}
+		if tok != want {
+			v.errorAt(offset, "want %v", want)
+			return false
+		}
+		return true
+	}
+
+	if !delim('{') {
+		return
+	}
+	for dec.More() {
+		keyOffset := v.next(dec)
+		tok, err := dec.Token()
+		if fatal(err, keyOffset, "") {
+			return
+		}
+		key, _ := tok.(string)
+		valueOffset := v.next(dec)
+		offsets[key] = valueOffset
+		switch key {
+		case "version":
+			err = dec.Decode(&lf.Version)
+		case "name":
+			err = dec.Decode(&lf.Name)
+		case "background":
+			err = dec.Decode(&lf.Background)
+		case "ballSpeed":
+			err = dec.Decode(&lf.BallSpeed)
+		case "paddleSize":
+			err = dec.Decode(&lf.PaddleSize)
+		case "paddleStart":
+			err = dec.Decode(&lf.PaddleStart)
+		case "spawners":
+			err = dec.Decode(&lf.Spawners)
+		case "palette":
+			lf.Palette = map[string]BrickType{}
+			if !delim('{') {
+				return
+			}
+			for dec.More() {
+				offset := v.next(dec)
+				tok, err := dec.Token()
+				if fatal(err, offset, "") {
+					return
+				}
+				name, _ := tok.(string)
+				valueOffset := v.next(dec)
+				var brick BrickType
+				if fatal(dec.Decode(&brick), valueOffset, "palette."+name) {
+					return
+				}
+				lf.Palette[name] = brick
+				paletteOffsets[name] = offset
+			}
+			if !delim('}') {
+				return
+			}
+			continue
+		case "bricks":
+			if !delim('[') {
+				return
+			}
+			for dec.More() {
+				offset := v.next(dec)
+				var line string
+				if fatal(dec.Decode(&line), offset, "bricks") {
+					return
+				}
+				var row []tile
+				for _, t := range fields(line) {
+					// one past the opening quote, assuming no escapes
+					t.offset += offset + 1
+					row = append(row, t)
+				}
+				if len(row) > 0 {
+					rows = append(rows, row)
+					rowEnds = append(rowEnds, int(dec.InputOffset())-1)
+				}
+			}
+			if !delim(']') {
+				return
+			}
+			continue
+		default:
+			v.errorAt(keyOffset, "unknown field %q", key)
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if fatal(err, valueOffset, key) {
+			return
+		}
+	}
+	if !delim('}') {
+		return
+	}
+
+	if offset, ok := offsets["version"]; !ok {
+		v.errorAt(0, "missing version")
+	} else if !bad["version"] && (lf.Version < 1 || lf.Version > levelVersion) {
+		v.errorAt(offset, "unsupported version %d, want 1 to %d", lf.Version, levelVersion)
+	}
+	if lf.BallSpeed < 0 {
+		v.errorAt(offsets["ballSpeed"], "ballSpeed is negative")
+	}
+	if lf.PaddleSize != nil && (lf.PaddleSize.X() <= 0 || lf.PaddleSize.Y() <= 0) {
+		v.errorAt(offsets["paddleSize"], "paddleSize must be positive")
+	}
+	for i, spawner := range lf.Spawners {
+		if _, ok := powerUpByName(spawner.PowerUp); !ok {
+			v.errorAt(offsets["spawners"], "spawner %d drops unknown power-up %q", i+1, spawner.PowerUp)
+		}
+		if spawner.Interval < 0 {
+			v.errorAt(offsets["spawners"], "spawner %d has a negative interval", i+1)
+		}
+	}
+	if _, ok := offsets["palette"]; !ok {
+		v.errorAt(0, "missing palette")
+	}
+	var keys []string
+	for key := range lf.Palette {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		brick, offset := lf.Palette[key], paletteOffsets[key]
+		if emptyTile(key) {
+			v.errorAt(offset, "brick %q can't be used, %q means no brick", key, key)
+		} else if strings.ContainsAny(key, " \t\r\n") {
+			v.errorAt(offset, "brick %q has spaces in its key", key)
+		}
+		if !bad["palette."+key+".hitPoints"] && brick.HitPoints < 1 {
+			v.errorAt(offset, "brick %q has %d hit points, want at least 1", key, brick.HitPoints)
+		}
+		for name, chance := range brick.Drops {
+			if _, ok := powerUpByName(name); !ok {
+				v.errorAt(offset, "brick %q drops unknown power-up %q", key, name)
+			}
+			if chance < 0 {
+				v.errorAt(offset, "brick %q has a negative chance of dropping %q", key, name)
+			}
+		}
+	}
+	for _, row := range rows {
+		for _, t := range row {
+			if _, ok := lf.Palette[t.key]; !ok && !emptyTile(t.key) {
+				v.errorAt(t.offset, "unknown brick %q, it isn't in the palette", t.key)
+			}
+		}
+	}
+	v.checkBricks(rows, rowEnds, func(key string) bool {
+		brick, ok := lf.Palette[key]
+		return ok && !emptyTile(key) && !brick.Solid
+	})
+}
+
+// validateTiled checks a Tiled map. Only problems reading it have a line and
+// column, the rest are in terms of the map.
+func (v *validator) validateTiled(tmx bool) {
+	l := parseTiledLevel(v.data, tmx, v.errorAt)
+	if l == nil {
+		return
+	}
+	rows := make([][]tile, len(l.Tiles))
+	for y, keys := range l.Tiles {
+		for _, key := range keys {
+			rows[y] = append(rows[y], tile{key: key, offset: -1})
+		}
+	}
+	// tile layers are all the map's size, so never ragged
+	v.checkBricks(rows, nil, func(key string) bool {
+		brick, ok := l.Palette[key]
+		return ok && !brick.Solid
+	})
+}
+
+// next is the offset of the next token dec will read.
+func (v *validator) next(dec *json.Decoder) int {
+	offset := int(dec.InputOffset())
+	for offset < len(v.data) && strings.IndexByte(" \t\r\n,:", v.data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// levelFiles lists the level files, .txt, .json or .tmx, in dir of fsys in natural
+// order so that 2.txt comes before 10.txt. The editor's copies of imported
+// levels are left out, they are read in place of the level.
+func levelFiles(fsys fs.FS, dir string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, editedSuffix) {
+			continue
+		}
+		if ext := path.Ext(name); !entry.IsDir() && (ext == ".txt" || ext == ".json" || ext == ".tmx") {
+			files = append(files, name)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return naturalLess(files[i], files[j]) })
+	for i, file := range files {
+		files[i] = path.Join(dir, file)
+	}
+	return files, nil
+}
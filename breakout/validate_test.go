@@ -0,0 +1,81 @@
+package breakout
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateLevel(t *testing.T) {
+	tests := []struct {
+		name, file, data string
+		want             []string
+	}{
+		{"legacy", "1.txt", "1 2\n2 2\n", nil},
+		{"legacy not a number", "1.txt", "2 x\n2 2", []string{
+			`1.txt:1:3: "x" is not a brick code`,
+		}},
+		{"legacy unknown code", "1.txt", "2 9", []string{
+			`1.txt:1:3: unknown brick code 9, want 0 to 5`,
+		}},
+		{"legacy ragged", "1.txt", "2 2\n2\n2 2 2", []string{
+			`1.txt:2:2: row has 1 bricks, want 2 like the first row`,
+			`1.txt:3:5: row has 3 bricks, want 2 like the first row`,
+		}},
+		{"legacy empty", "1.txt", "\n\n", []string{
+			`1.txt: level has no bricks`,
+		}},
+		{"legacy all gaps", "1.txt", "0 0", []string{
+			`1.txt: level has no bricks that can be destroyed`,
+		}},
+		{"legacy all solid", "1.txt", "1 1", []string{
+			`1.txt: level has no bricks that can be destroyed`,
+		}},
+		{"json", "a.json", `{"version": 1, "palette": {"x": {"hitPoints": 1}}, "bricks": ["x ."]}`, nil},
+		{"json version too new", "a.json", `{"version": 2, "palette": {"x": {"hitPoints": 1}}, "bricks": ["x"]}`, []string{
+			`a.json:1:13: unsupported version 2, want 1 to 1`,
+		}},
+		{"json version too old", "a.json", `{"version": 0, "palette": {"x": {"hitPoints": 1}}, "bricks": ["x"]}`, []string{
+			`a.json:1:13: unsupported version 0, want 1 to 1`,
+		}},
+		// only the type is wrong, not the version as well
+		{"json version not a number", "a.json", `{"version": "1", "palette": {"x": {"hitPoints": 1}}, "bricks": ["x"]}`, []string{
+			`a.json:1:13: version is a string, want int`,
+		}},
+		{"json missing version", "a.json", `{"palette": {"x": {"hitPoints": 1}}, "bricks": ["x"]}`, []string{
+			`a.json:1:1: missing version`,
+		}},
+		// reported at the start of the brick, not after it
+		{"json palette type", "a.json", "{\n\"version\": 1,\n\"palette\": {\n  \"x\": {\"hitPoints\": \"lots\"}\n},\n\"bricks\": [\"x\"]\n}", []string{
+			`a.json:4:8: palette.x.hitPoints is a string, want int`,
+		}},
+		{"json palette", "a.json", "{\"version\": 1,\n\"palette\": {\n  \".\": {\"hitPoints\": 1},\n  \"x\": {\"hitPoints\": 0, \"drops\": {\"nope\": 10}}\n},\n\"bricks\": [\"x\"]}", []string{
+			`a.json:3:3: brick "." can't be used, "." means no brick`,
+			`a.json:4:3: brick "x" has 0 hit points, want at least 1`,
+			`a.json:4:3: brick "x" drops unknown power-up "nope"`,
+		}},
+		{"json bricks", "a.json", "{\"version\": 1, \"palette\": {\"x\": {\"hitPoints\": 1}},\n\"bricks\": [\n  \"x x\",\n  \"x y\",\n  \"x\"\n]}", []string{
+			`a.json:4:6: unknown brick "y", it isn't in the palette`,
+			`a.json:5:5: row has 1 bricks, want 2 like the first row`,
+		}},
+		{"json unknown field", "a.json", `{"version": 1, "colour": 1, "palette": {"x": {"hitPoints": 1}}, "bricks": ["x"]}`, []string{
+			`a.json:1:16: unknown field "colour"`,
+		}},
+		{"json syntax", "a.json", "{\"version\": 1,\n\"palette\" {}}", []string{
+			`a.json:2:12: invalid character '{' after object key`,
+		}},
+		{"json truncated", "a.json", `{"version": 1, "palette": {`, []string{
+			`a.json:1:28: unexpected end of JSON input`,
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, d := range ValidateLevel(test.file, []byte(test.data)) {
+				got = append(got, d.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("diagnostics\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
@@ -8,4 +8,4 @@ require (
 	golang.org/x/image v0.0.0-20190910094157-69e4b8554b2a
 )
 
-go 1.13
+go 1.16